@@ -0,0 +1,36 @@
+package deploy
+
+import (
+	"embed"
+	"fmt"
+)
+
+// Templates holds the built-in Milvus spec templates, one <type>_<mode>.yaml per size class
+//
+//go:embed *.yaml
+var Templates embed.FS
+
+var (
+	// Types are the size classes of the built-in templates
+	Types = []string{"minimal", "medium", "large"}
+	// Modes are the milvus modes of the built-in templates
+	Modes = []string{"standalone", "cluster"}
+)
+
+// FileName returns the name of the built-in template for the type and mode,
+// any mode other than standalone falls back to cluster like the create command does
+func FileName(templateType, mode string) string {
+	if mode != "standalone" {
+		mode = "cluster"
+	}
+	return templateType + "_" + mode + ".yaml"
+}
+
+// Read returns the content of the built-in template for the type and mode
+func Read(templateType, mode string) ([]byte, error) {
+	data, err := Templates.ReadFile(FileName(templateType, mode))
+	if err != nil {
+		return nil, fmt.Errorf("built-in template %s/%s not found", templateType, mode)
+	}
+	return data, nil
+}
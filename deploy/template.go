@@ -0,0 +1,203 @@
+package deploy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// TemplatesEnv holds extra template directories, separated like PATH
+	TemplatesEnv = "MILVUSCTL_TEMPLATES"
+	// BaseKey names the template a template is layered on, it is not part of the milvus spec
+	BaseKey = "base"
+	// SourceBuiltin is the source of the templates bundled into milvusctl
+	SourceBuiltin = "built-in"
+
+	templateExt = ".yaml"
+)
+
+// Template describes a template found on the search path
+type Template struct {
+	Name   string
+	Source string
+}
+
+// SearchPath returns the directories searched for user templates in priority order:
+// the --template-dir flag, $MILVUSCTL_TEMPLATES and ~/.milvusctl/templates
+func SearchPath(templateDir string) []string {
+	dirs := []string{}
+	if templateDir != "" {
+		dirs = append(dirs, templateDir)
+	}
+	for _, dir := range filepath.SplitList(os.Getenv(TemplatesEnv)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".milvusctl", "templates"))
+	}
+	return dirs
+}
+
+// IsBuiltinType reports if the name is one of the built-in size classes
+func IsBuiltinType(name string) bool {
+	for _, t := range Types {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the raw content of the named template.
+// Built-in size classes are resolved with the mode first, then <name>.yaml is
+// looked up in the search path and finally among the built-in files, so
+// "medium_cluster" is a valid name as well.
+func Find(name, mode string, dirs []string) (*Template, []byte, error) {
+	if IsBuiltinType(name) {
+		data, err := Read(name, mode)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &Template{Name: name, Source: SourceBuiltin}, data, nil
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, nil, fmt.Errorf("invalid template name %q", name)
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name+templateExt)
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			return &Template{Name: name, Source: path}, data, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+	}
+	if data, err := Templates.ReadFile(name + templateExt); err == nil {
+		return &Template{Name: name, Source: SourceBuiltin}, data, nil
+	}
+	return nil, nil, fmt.Errorf("template %q not found, use one of the built-in types %s or a %s%s file in: %s",
+		name, strings.Join(Types, ", "), name, templateExt, strings.Join(dirs, ", "))
+}
+
+// Load returns the named template with all of its bases merged in, the
+// fields of a template overwrite the ones of its base
+func Load(name, mode string, dirs []string) (map[string]interface{}, error) {
+	return load(name, mode, dirs, []string{})
+}
+
+func load(name, mode string, dirs []string, chain []string) (map[string]interface{}, error) {
+	for _, n := range chain {
+		if n == name {
+			return nil, fmt.Errorf("template base cycle: %s -> %s", strings.Join(chain, " -> "), name)
+		}
+	}
+	chain = append(chain, name)
+
+	tpl, data, err := Find(name, mode, dirs)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed parsing template %s: %v", tpl.Source, err)
+	}
+
+	baseValue, ok := doc[BaseKey]
+	if !ok {
+		return doc, nil
+	}
+	delete(doc, BaseKey)
+	baseName, ok := baseValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("template %s: %s must be a template name", tpl.Source, BaseKey)
+	}
+	// a base size class follows the requested mode, or the mode of the template itself
+	if mode == "" {
+		mode, _ = doc["mode"].(string)
+	}
+	base, err := load(baseName, mode, dirs, chain)
+	if err != nil {
+		return nil, err
+	}
+	return MergeValues(base, doc), nil
+}
+
+// MergeValues merges the override into the base, nested tables are merged
+// key by key while any other value, lists included, is replaced
+func MergeValues(base, override map[string]interface{}) map[string]interface{} {
+	for key, value := range override {
+		if baseTable, ok := base[key].(map[string]interface{}); ok {
+			if table, ok := value.(map[string]interface{}); ok {
+				base[key] = MergeValues(baseTable, table)
+				continue
+			}
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// List returns the built-in templates followed by the user templates of the
+// search path with the source Find resolves them to: a user template shadowed by
+// an earlier directory is skipped and a built-in file shadowed by a user template
+// is listed with the source of the user template
+func List(dirs []string) ([]Template, error) {
+	users := []Template{}
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		names := []string{}
+		for _, file := range files {
+			if file.IsDir() || filepath.Ext(file.Name()) != templateExt {
+				continue
+			}
+			names = append(names, strings.TrimSuffix(file.Name(), templateExt))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if seen[name] || IsBuiltinType(name) {
+				continue
+			}
+			seen[name] = true
+			users = append(users, Template{Name: name, Source: filepath.Join(dir, name+templateExt)})
+		}
+	}
+
+	list := []Template{}
+	listed := map[string]bool{}
+	for _, t := range Types {
+		for _, m := range Modes {
+			name := strings.TrimSuffix(FileName(t, m), templateExt)
+			list = append(list, Template{Name: name, Source: SourceBuiltin})
+			listed[name] = true
+		}
+	}
+	for _, user := range users {
+		if !listed[user.Name] {
+			list = append(list, user)
+			continue
+		}
+		// the user template shadows the built-in file of the same name
+		for i := range list {
+			if list[i].Name == user.Name {
+				list[i].Source = user.Source
+			}
+		}
+	}
+	return list, nil
+}
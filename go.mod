@@ -3,17 +3,18 @@ module github.com/milvus-io/milvusctl
 go 1.17
 
 require (
-	github.com/ghodss/yaml v1.0.0
 	github.com/jetstack/cert-manager v1.6.1
 	github.com/milvus-io/milvus-operator v0.5.0
 	github.com/opentracing/opentracing-go v1.2.0
 	github.com/pkg/errors v0.9.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/cobra v1.3.0
 	helm.sh/helm/v3 v3.7.2
 	k8s.io/api v0.23.0
 	k8s.io/cli-runtime v0.22.4
 	k8s.io/kubectl v0.22.4
 	k8s.io/kubernetes v1.13.0
+	k8s.io/utils v0.0.0-20211208161948-7d6a63dca704
 	sigs.k8s.io/controller-runtime v0.11.0
 	sigs.k8s.io/yaml v1.3.0
 )
@@ -115,7 +116,6 @@ require (
 	github.com/opencontainers/image-spec v1.0.1 // indirect
 	github.com/opencontainers/runc v1.0.2 // indirect
 	github.com/peterbourgon/diskv v2.0.1+incompatible // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/prometheus/client_golang v1.11.0 // indirect
 	github.com/prometheus/client_model v0.2.0 // indirect
 	github.com/prometheus/common v0.28.0 // indirect
@@ -153,7 +153,6 @@ require (
 	k8s.io/apiserver v0.23.0 // indirect
 	k8s.io/klog/v2 v2.40.1 // indirect
 	k8s.io/kube-openapi v0.0.0-20211115234752-e816edb12b65 // indirect
-	k8s.io/utils v0.0.0-20211208161948-7d6a63dca704 // indirect
 	oras.land/oras-go v0.4.0 // indirect
 	sigs.k8s.io/json v0.0.0-20211208200746-9f7c6b3444d2 // indirect
 	sigs.k8s.io/kustomize/api v0.10.1 // indirect
@@ -3,11 +3,12 @@ package create
 import (
 	"context"
 	"fmt"
-	"github.com/ghodss/yaml"
 	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/deploy"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"github.com/milvus-io/milvusctl/pkg/override"
 	pkgerr "github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"helm.sh/helm/v3/pkg/strvals"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -18,17 +19,22 @@ import (
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
 	// "log"
-	"io/ioutil"
 	"os"
-	"path"
-	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strings"
+	"time"
 )
 
 var (
 	createLong = templates.LongDesc(i18n.T(`
 		The create subcommand installs the milvus version like standalone or cluster in the cluster
+
+		The type is one of the built-in size classes minimal, medium or large, or the name of
+		a <type>.yaml milvus spec found in --template-dir, $MILVUSCTL_TEMPLATES or
+		~/.milvusctl/templates. A template can set "base: <type>" to layer on another template.
+
+		Use --dry-run=client -o yaml to print the final milvus manifest without creating it, or
+		--dry-run=server to have the server and the operator webhook validate it.
     `))
 )
 
@@ -36,7 +42,10 @@ type printFn func(format string, v ...interface{})
 type MilvusCreateOptions struct {
 	Mode           string
 	Type           string
+	TemplateDir    string
 	Values         []string
+	Wait           bool
+	Timeout        time.Duration
 	Namespace      string
 	CreateOptions  *kubectlcreate.CreateOptions
 	ResouceSetting map[string]interface{}
@@ -50,7 +59,7 @@ func NewMivlusCreateOptions(ioStreams genericclioptions.IOStreams) *MilvusCreate
 		CreateOptions: kubectlcreate.NewCreateOptions(ioStreams),
 	}
 }
-func NewMilvusCreateCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, client *client.Client) *cobra.Command {
+func NewMilvusCreateCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, c *client.Client) *cobra.Command {
 	o := NewMivlusCreateOptions(ioStreams)
 	createCmd := &cobra.Command{
 		Use:   "create instance_name {-f filename | -t type -m model}",
@@ -66,7 +75,7 @@ func NewMilvusCreateCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(o.Complete(f, cmd))
 			cmdutil.CheckErr(o.ValidateArgs(cmd, args))
-			cmdutil.CheckErr(o.Run(f, cmd, client, args))
+			cmdutil.CheckErr(o.Run(f, cmd, c, args))
 		},
 	}
 	o.CreateOptions.RecordFlags.AddFlags(createCmd)
@@ -80,8 +89,11 @@ func NewMilvusCreateCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams
 
 	createCmd.Flags().StringVarP(&o.Mode, "mode", "m", o.Mode, "use mode parameter to choose milvus standalone or cluster")
 	// createCmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "use type parameter to choose install namespace")
-	createCmd.Flags().StringVarP(&o.Type, "type", "t", o.Type, "use type parameter to choose milvus cluster minimal,medium or large")
+	createCmd.Flags().StringVarP(&o.Type, "type", "t", o.Type, "use type parameter to choose milvus cluster minimal,medium, large or a template name")
+	createCmd.Flags().StringVar(&o.TemplateDir, "template-dir", o.TemplateDir, "the directory searched first for <type>.yaml templates, before $"+deploy.TemplatesEnv+" and ~/.milvusctl/templates")
 	createCmd.Flags().StringArrayVar(&o.Values, "set", []string{}, "the resource requirement requests for milvus cluster")
+	createCmd.Flags().BoolVar(&o.Wait, "wait", o.Wait, "If true, wait for the milvus instance to become Healthy and print the progress of its components")
+	createCmd.Flags().DurationVar(&o.Timeout, "timeout", milvus.DefaultWaitTimeout, "The length of time to wait for the milvus instance to become Healthy when --wait is set")
 	// _ = createCmd.MarkFlagRequired("mode")
 
 	return createCmd
@@ -99,18 +111,19 @@ func (o *MilvusCreateOptions) Complete(f cmdutil.Factory, cmd *cobra.Command) er
 	return nil
 }
 func (o *MilvusCreateOptions) ValidateArgs(cmd *cobra.Command, args []string) error {
+	if o.Wait && o.CreateOptions.DryRunStrategy != cmdutil.DryRunNone {
+		return fmt.Errorf("--wait can not be used with --dry-run")
+	}
 	if len(o.Values) > 0 {
-		base := map[string]interface{}{}
-		for _, value := range o.Values {
-			if err := strvals.ParseInto(value, base); err != nil {
-				return pkgerr.Wrap(err, "failed parsing --set data")
-			}
+		base, err := override.Parse(o.Values)
+		if err != nil {
+			return err
 		}
 		o.ResouceSetting = base
 	}
 	return nil
 }
-func (o *MilvusCreateOptions) Run(f cmdutil.Factory, cmd *cobra.Command, client *client.Client, args []string) error {
+func (o *MilvusCreateOptions) Run(f cmdutil.Factory, cmd *cobra.Command, c *client.Client, args []string) error {
 	if len(o.CreateOptions.FilenameOptions.Filenames) > 0 {
 		if err := o.CreateOptions.RunCreate(f, cmd); err != nil {
 			return err
@@ -122,19 +135,38 @@ func (o *MilvusCreateOptions) Run(f cmdutil.Factory, cmd *cobra.Command, client
 		return cmdutil.UsageErrorf(cmd, "accepts 1 arg(s), received %v", len(args))
 	}
 
-	if _, err := o.newMilvusInstance(*client, context.TODO(), args[0]); err != nil {
+	newMilvus, err := o.newMilvusInstance(*c, context.TODO(), args[0])
+	if err != nil {
+		return err
+	}
+	// the printers need the type meta which the typed client does not keep
+	newMilvus.SetGroupVersionKind(v1beta1.GroupVersion.WithKind("Milvus"))
+	if err := o.CreateOptions.PrintObj(newMilvus); err != nil {
 		return err
 	}
+
+	if o.Wait {
+		waiter := &milvus.Waiter{
+			Client:    *c,
+			Namespace: o.Namespace,
+			Name:      args[0],
+			Timeout:   o.Timeout,
+			Out:       o.CreateOptions.Out,
+		}
+		return waiter.WaitForHealthy(context.TODO())
+	}
 	return nil
 }
 
-func (o *MilvusCreateOptions) newMilvusInstance(client client.Client, ctx context.Context, instanceName string) (*v1beta1.Milvus, error) {
+func (o *MilvusCreateOptions) newMilvusInstance(c client.Client, ctx context.Context, instanceName string) (*v1beta1.Milvus, error) {
 	namespacedName := types.NamespacedName{
 		Name:      instanceName,
 		Namespace: o.Namespace,
 	}
 
-	if !errors.IsNotFound(client.Get(ctx, namespacedName, &v1beta1.Milvus{})) {
+	// a client dry run only renders the manifest, so it does not look at the cluster
+	if o.CreateOptions.DryRunStrategy != cmdutil.DryRunClient &&
+		!errors.IsNotFound(c.Get(ctx, namespacedName, &v1beta1.Milvus{})) {
 		return nil, fmt.Errorf("Error: milvuses.milvus.io %s already exists", instanceName)
 	}
 
@@ -149,234 +181,50 @@ func (o *MilvusCreateOptions) newMilvusInstance(client client.Client, ctx contex
 		},
 	}
 
-	switch o.Type {
-	case "minimal":
-		if o.Mode == "standalone" {
-			spec, err := yamlToObj("minimal_standalone.yaml")
-			if err != nil {
-				return nil, err
-			}
-			newMilvus.Spec = *spec
-		} else {
-			spec, err := yamlToObj("minimal_cluster.yaml")
-			if err != nil {
-				return nil, err
-			}
-			newMilvus.Spec = *spec
-		}
-	case "medium":
-		if o.Mode == "standalone" {
-			spec, err := yamlToObj("medium_standalone.yaml")
-			if err != nil {
-				return nil, err
-			}
-			newMilvus.Spec = *spec
-		} else {
-			spec, err := yamlToObj("medium_cluster.yaml")
-			if err != nil {
-				return nil, err
-			}
-			newMilvus.Spec = *spec
-		}
-	case "large":
-		if o.Mode == "standalone" {
-			spec, err := yamlToObj("large_standalone.yaml")
-			if err != nil {
-				return nil, err
-			}
-			newMilvus.Spec = *spec
-		} else {
-			spec, err := yamlToObj("large_cluster.yaml")
-			if err != nil {
-				return nil, err
-			}
-			newMilvus.Spec = *spec
-		}
-	default:
-		return nil, fmt.Errorf("Error type, please specify one of the following types: 'minimal', 'medium', 'large'")
+	spec, err := templateToObj(o.Type, o.Mode, deploy.SearchPath(o.TemplateDir))
+	if err != nil {
+		return nil, err
+	}
+	newMilvus.Spec = *spec
+	if o.Mode != "" {
+		newMilvus.Spec.Mode = v1beta1.MilvusMode(o.Mode)
 	}
 
-	err := parsingNestedStructure(reflect.ValueOf(&newMilvus.Spec).Elem(), o.ResouceSetting)
+	err = override.Apply(&newMilvus.Spec, o.ResouceSetting)
 	if err != nil {
 		return nil, err
 	}
 	// fmt.Println("Dest Milvus cluster spec", newMilvusCluster.Spec)
-	if err = client.Create(ctx, newMilvus); err != nil {
+	switch o.CreateOptions.DryRunStrategy {
+	case cmdutil.DryRunClient:
+		return newMilvus, nil
+	case cmdutil.DryRunServer:
+		err = c.Create(ctx, newMilvus, client.DryRunAll)
+	default:
+		err = c.Create(ctx, newMilvus)
+	}
+	if err != nil {
 		return nil, err
 	}
 	return newMilvus, nil
 }
 
-func yamlToObj(fileName string) (*v1beta1.MilvusSpec, error) {
-	filePath := path.Join("deploy", fileName)
+// templateToObj loads the named template with its bases merged into a milvus spec
+func templateToObj(name string, mode string, dirs []string) (*v1beta1.MilvusSpec, error) {
+	if name == "" {
+		return nil, fmt.Errorf("Error type, please specify one of the following types: '%s' or a template name", strings.Join(deploy.Types, "', '"))
+	}
 	var milvusSpec v1beta1.MilvusSpec
-	ymlSpec, err := ioutil.ReadFile(filePath)
+	values, err := deploy.Load(name, mode, dirs)
 	if err != nil {
 		return nil, err
 	}
-	jsSpec, err := yaml.YAMLToJSON([]byte(ymlSpec))
+	jsSpec, err := json.Marshal(values)
 	if err != nil {
 		return nil, err
 	}
 	if err := json.Unmarshal(jsSpec, &milvusSpec); err != nil {
-		return nil, err
+		return nil, pkgerr.Wrapf(err, "template %s is not a valid milvus spec", name)
 	}
 	return &milvusSpec, nil
 }
-
-func parsingNestedStructure(v reflect.Value, values map[string]interface{}) error {
-	var err error
-
-	if values == nil {
-		return err
-	}
-
-	if v.Type().String() == "v1beta1.Values" {
-		srcValue := v.FieldByName("Data").Interface().(map[string]interface{})
-		newMapValue := mapOverwirte(srcValue, values)
-		v.FieldByName("Data").Set(reflect.ValueOf(newMapValue).Convert(v.FieldByName("Data").Type()))
-		return err
-	}
-
-	tagMap := getTagMap(v.Type())
-
-	for key, value := range values {
-		field, ok := tagMap[key]
-		if ok {
-			subSpec := v.FieldByName(field)
-			setValue(subSpec, value)
-		} else if _, ok := tagMap[",inline"]; ok {
-			if tagMap[",inline"] == "ComponentSpec" {
-				if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ComponentSpec{}), key); ok {
-					subSpec := v.FieldByName("ComponentSpec").FieldByName(field)
-					setValue(subSpec, value)
-				} else {
-					err = fmt.Errorf("The field %q does not exists", key)
-				}
-			} else if tagMap[",inline"] == "Component" {
-				if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.Component{}), key); ok {
-					subSpec := v.FieldByName("Component").FieldByName(field)
-					setValue(subSpec, value)
-				} else if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ComponentSpec{}), key); ok {
-					subSpec := v.FieldByName("Component").FieldByName("ComponentSpec").FieldByName(field)
-					setValue(subSpec, value)
-				} else {
-					err = fmt.Errorf("The field %q does not exists", key)
-				}
-			} else if tagMap[",inline"] == "ServiceComponent" {
-				if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ServiceComponent{}), key); ok {
-					subSpec := v.FieldByName("ServiceComponent").FieldByName(field)
-					setValue(subSpec, value)
-				} else if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.Component{}), key); ok {
-					subSpec := v.FieldByName("ServiceComponent").FieldByName("Component").FieldByName(field)
-					setValue(subSpec, value)
-				} else if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ComponentSpec{}), key); ok {
-					subSpec := v.FieldByName("ServiceComponent").FieldByName("Component").FieldByName("ComponentSpec").FieldByName(field)
-					setValue(subSpec, value)
-				} else {
-					err = fmt.Errorf("The field %q does not exists", key)
-				}
-			}
-		} else {
-			err = fmt.Errorf("The field %q does not exists", key)
-		}
-	}
-	return err
-}
-
-// Overwrite the Milvus Spev with the setting values
-func setValue(subSpec reflect.Value, value interface{}) error {
-	var err error
-	if subSpec.Kind() == reflect.Struct {
-		if istable(value) {
-			parsingNestedStructure(subSpec, value.(map[string]interface{}))
-		} else {
-			err = fmt.Errorf("Can not be overwritten with value %q", value)
-		}
-	} else if subSpec.Kind() == reflect.Ptr {
-		if subSpec.IsNil() {
-			newSpec := reflect.New(subSpec.Type().Elem())
-			if newSpec.Type().Elem().Kind() == reflect.Struct {
-				if istable(value) {
-					parsingNestedStructure(newSpec.Elem(), value.(map[string]interface{}))
-				} else {
-					err = fmt.Errorf("Can not be overwritten with value %q", value)
-				}
-			} else {
-				setValue(newSpec.Elem(), value)
-			}
-			subSpec.Set(newSpec)
-		} else {
-			if subSpec.Type().Elem().Kind() == reflect.Struct {
-				if istable(value) {
-					parsingNestedStructure(subSpec.Elem(), value.(map[string]interface{}))
-				} else {
-					err = fmt.Errorf("Can not be overwritten with value %q", value)
-				}
-			} else {
-				setValue(subSpec.Elem(), value)
-			}
-		}
-		// } else if subSpec.Kind() == reflect.Map{
-		// 	subSpec.Set(reflect.ValueOf(value).Convert(subSpec.Type()))
-	} else {
-		subSpec.Set(reflect.ValueOf(value).Convert(subSpec.Type()))
-	}
-	return err
-}
-
-func mapOverwirte(src, values map[string]interface{}) map[string]interface{} {
-	if values == nil {
-		return src
-	}
-	for key, value := range values {
-		if sv, ok := src[key]; ok && sv != nil {
-			if istable(value) && istable(sv) {
-				mapOverwirte(sv.(map[string]interface{}), value.(map[string]interface{}))
-			} else if !istable(value) && !istable(sv) {
-				src[key] = value
-			} else {
-				fmt.Println("can't overwritter")
-				continue
-			}
-		} else {
-			src[key] = value
-		}
-	}
-	return src
-}
-
-func ifTagInSpec(t reflect.Type, key string) (string, bool) {
-	tagMap := getTagMap(t)
-	if field, ok := tagMap[key]; ok {
-		return field, true
-	} else {
-		return "", false
-	}
-}
-
-func getTagMap(t reflect.Type) map[string]string {
-	tagMap := make(map[string]string)
-	var tag string
-	for i := 0; i < t.NumField(); i++ {
-		tag = readTag(t.Field(i).Tag.Get("json"))
-		tagMap[tag] = t.Field(i).Name
-	}
-	return tagMap
-}
-
-// istable is a special-purpose function to see if the present thing matches the definition of a YAML table.
-func istable(v interface{}) bool {
-	_, ok := v.(map[string]interface{})
-	return ok
-}
-
-func readTag(tag string) string {
-	if tag != "" {
-		res := strings.Split(tag, ",")
-		if res[0] != "" {
-			return res[0]
-		}
-	}
-	return tag
-}
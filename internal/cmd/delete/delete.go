@@ -1,114 +1,361 @@
 package delete
 
 import (
+	"bufio"
 	"context"
-	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1alpha1"
+	"fmt"
+	"strings"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	kubectldelete "k8s.io/kubectl/pkg/cmd/delete"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var (
+	deleteExample = templates.Examples(`
+		# Delete the milvus instance my-release, keeping the volumes of its dependences
+		milvusctl delete my-release
+
+		# Delete several milvus instances together with the volumes of their in-cluster dependences
+		milvusctl delete my-release other-release --with-deletion
+
+		# Delete every milvus instance labeled env=test without asking for confirmation
+		milvusctl delete -l env=test --yes
+
+		# Delete my-release and wait until the operator has torn down its workloads
+		milvusctl delete my-release --wait --timeout 5m`)
+)
+
 type MilvusDeleteOptions struct {
-	WithDeletions   bool
-	Namespace       string
-	Deleteflags     *kubectldelete.DeleteFlags
-	DeletionOptions *kubectldelete.DeleteOptions
+	WithDeletions    bool
+	Yes              bool
+	ForceUnprotected bool
+	Names            []string
+	Selector         string
+	IgnoreNotFound   bool
+	Namespace        string
+	DryRunStrategy   cmdutil.DryRunStrategy
+	Deleteflags      *kubectldelete.DeleteFlags
+	DeletionOptions  *kubectldelete.DeleteOptions
+
+	genericclioptions.IOStreams
+}
+
+// pendingDeletion is a milvus instance to delete and the volumes that are deleted with it
+type pendingDeletion struct {
+	milvus *v1beta1.Milvus
+	pvcs   []string
 }
 
 func NewMivlusDeleteOptions(ioStreams genericclioptions.IOStreams) *MilvusDeleteOptions {
 	deletflags := kubectldelete.NewDeleteFlags("containing the milvus  to delete.")
+	// milvus instances are deleted by name or selector, not from files
+	deletflags.FileNameFlags = nil
+	selector := ""
+	ignoreNotFound := false
+	now := false
+	deletflags.LabelSelector = &selector
+	deletflags.IgnoreNotFound = &ignoreNotFound
+	deletflags.Now = &now
 	o, _ := deletflags.ToOptions(nil, ioStreams)
 	return &MilvusDeleteOptions{
 		Deleteflags:     deletflags,
 		WithDeletions:   false,
 		Namespace:       "default",
 		DeletionOptions: o,
+		IOStreams:       ioStreams,
 	}
 }
-func NewMilvusDeleteCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, client *client.Client) *cobra.Command {
+func NewMilvusDeleteCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, c *client.Client) *cobra.Command {
 	o := NewMivlusDeleteOptions(ioStreams)
 	deleteCmd := &cobra.Command{
-		Use:   "delete",
-		Short: "delete milvus in kubernetes cluster",
-		Long:  "The deelte subcommand uninstalls the milvus version like standalone or cluster in the cluster",
-		PreRun: func(cmd *cobra.Command, args []string) {
-
-		},
+		Use:     "delete (NAME... | -l selector)",
+		Short:   "delete milvus in kubernetes cluster",
+		Long:    "The delete subcommand uninstalls milvus instances by name or label selector, it lists the persistent volume claims that are deleted with them and asks for confirmation first",
+		Example: deleteExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(o.Run(client))
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run(c))
 		},
 	}
 	o.Deleteflags.AddFlags(deleteCmd)
+	deleteCmd.Flags().Lookup("wait").Usage = "If true, wait for the operator to tear down the workloads of the milvus instances and report the persistent volume claims, services and secrets left behind"
+	deleteCmd.Flags().Lookup("timeout").Usage = "The length of time to wait for the deletion, zero means 10m"
 	cmdutil.AddDryRunFlag(deleteCmd)
-	deleteCmd.Flags().BoolVar(&o.WithDeletions, "with-deletion", o.WithDeletions, "automatically add pvc deletion parameter on deletion")
-	deleteCmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "use type parameter to choose delete namespace")
+	deleteCmd.Flags().BoolVar(&o.WithDeletions, "with-deletion", o.WithDeletions, "delete the persistent volume claims of the in-cluster etcd, storage, pulsar and kafka with the milvus instance")
+	deleteCmd.Flags().BoolVar(&o.ForceUnprotected, "force-unprotected", o.ForceUnprotected, "If true, delete milvus instances even if they are protected by milvusctl protect")
+	deleteCmd.Flags().BoolVarP(&o.Yes, "yes", "y", o.Yes, "If true, delete without asking for confirmation")
 
 	return deleteCmd
 }
-func (o *MilvusDeleteOptions) Run(client *client.Client) error {
-	ctx := context.Background()
-	if err := o.deleteMilvus(*client, ctx); err != nil {
+
+func (o *MilvusDeleteOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
 		return err
 	}
-	if err := o.deleteMilvusCluster(*client, ctx); err != nil {
+	// the options have to be built again now that the flags are parsed
+	o.DeletionOptions, err = o.Deleteflags.ToOptions(nil, o.IOStreams)
+	if err != nil {
 		return err
 	}
+	o.Names = args
+	o.Selector = o.DeletionOptions.LabelSelector
+	o.IgnoreNotFound = o.DeletionOptions.IgnoreNotFound
+	// --now and --force set the grace period like they do for kubectl delete
+	if o.DeletionOptions.DeleteNow {
+		if o.DeletionOptions.GracePeriod != -1 {
+			return fmt.Errorf("--now and --grace-period cannot be specified together")
+		}
+		o.DeletionOptions.GracePeriod = 1
+	}
+	if o.DeletionOptions.GracePeriod == 0 && !o.DeletionOptions.ForceDeletion {
+		o.DeletionOptions.GracePeriod = 1
+	}
+	if o.DeletionOptions.ForceDeletion && o.DeletionOptions.GracePeriod < 0 {
+		o.DeletionOptions.GracePeriod = 0
+	}
 	return nil
 }
-func (o *MilvusDeleteOptions) deleteMilvus(client client.Client, ctx context.Context) error {
-	var milvus v1alpha1.Milvus
-	namespacedName := types.NamespacedName{
-		Name:      "milvus",
-		Namespace: o.Namespace,
-	}
-	err := client.Get(ctx, namespacedName, &milvus)
-	if errors.IsNotFound(err) {
-		return nil
+
+func (o *MilvusDeleteOptions) Validate() error {
+	if len(o.Names) == 0 && o.Selector == "" {
+		return fmt.Errorf("you must specify the name of a milvus instance or a label selector")
+	}
+	if o.DeletionOptions.WaitForDeletion && o.DryRunStrategy != cmdutil.DryRunNone {
+		return fmt.Errorf("--wait can not be used with --dry-run")
+	}
+	if len(o.Names) != 0 && o.Selector != "" {
+		return fmt.Errorf("name cannot be provided when a selector is specified")
 	}
-	if o.WithDeletions == true {
-		milvus.Spec.Dep.Etcd.InCluster.PVCDeletion = true
-		milvus.Spec.Dep.Etcd.InCluster.DeletionPolicy = "Delete"
-		milvus.Spec.Dep.Storage.InCluster.PVCDeletion = true
-		milvus.Spec.Dep.Storage.InCluster.DeletionPolicy = "Delete"
-		if err = client.Update(ctx, &milvus); err != nil {
+	if o.Selector != "" {
+		if _, err := labels.Parse(o.Selector); err != nil {
 			return err
 		}
 	}
-	err = client.Delete(ctx, &milvus)
-	if err != nil {
-		return err
+	switch {
+	case o.DeletionOptions.GracePeriod == 0 && o.DeletionOptions.ForceDeletion:
+		fmt.Fprintf(o.ErrOut, "warning: Immediate deletion does not wait for confirmation that the running resource has been terminated. The resource may continue to run on the cluster indefinitely.\n")
+	case o.DeletionOptions.GracePeriod > 0 && o.DeletionOptions.ForceDeletion:
+		return fmt.Errorf("--force and --grace-period greater than 0 cannot be specified together")
 	}
 	return nil
 }
-func (o *MilvusDeleteOptions) deleteMilvusCluster(client client.Client, ctx context.Context) error {
-	var mlc v1alpha1.MilvusCluster
-	namespacedName := types.NamespacedName{
-		Name:      "milvuscluster",
-		Namespace: o.Namespace,
-	}
-	err := client.Get(ctx, namespacedName, &mlc)
-	if errors.IsNotFound(err) {
+
+func (o *MilvusDeleteOptions) Run(c *client.Client) error {
+	ctx := context.Background()
+	milvuses, err := o.findMilvuses(*c, ctx)
+	if err != nil {
+		return err
+	}
+	if len(milvuses) == 0 {
+		fmt.Fprintf(o.ErrOut, "No resources found in %s namespace.\n", o.Namespace)
 		return nil
 	}
-	if o.WithDeletions == true {
-		mlc.Spec.Dep.Etcd.InCluster.PVCDeletion = true
-		mlc.Spec.Dep.Etcd.InCluster.DeletionPolicy = "Delete"
-		mlc.Spec.Dep.Storage.InCluster.PVCDeletion = true
-		mlc.Spec.Dep.Storage.InCluster.DeletionPolicy = "Delete"
-		mlc.Spec.Dep.Pulsar.InCluster.PVCDeletion = true
-		mlc.Spec.Dep.Pulsar.InCluster.DeletionPolicy = "Delete"
 
-		if err = client.Update(ctx, &mlc); err != nil {
+	if err := o.checkProtection(milvuses); err != nil {
+		return err
+	}
+
+	deletions := make([]pendingDeletion, 0, len(milvuses))
+	for i := range milvuses {
+		pvcs, err := o.pvcsToDelete(*c, ctx, &milvuses[i])
+		if err != nil {
 			return err
 		}
+		deletions = append(deletions, pendingDeletion{milvus: &milvuses[i], pvcs: pvcs})
 	}
-	err = client.Delete(ctx, &mlc)
-	if err != nil {
+	if err := o.confirmDeletion(deletions); err != nil {
 		return err
 	}
+
+	for _, deletion := range deletions {
+		if err := o.deleteMilvus(*c, ctx, deletion.milvus); err != nil {
+			return err
+		}
+	}
+
+	if o.DeletionOptions.WaitForDeletion {
+		allErrs := []error{}
+		for _, deletion := range deletions {
+			waiter := &milvus.Waiter{
+				Client:    *c,
+				Namespace: deletion.milvus.Namespace,
+				Name:      deletion.milvus.Name,
+				Timeout:   o.DeletionOptions.Timeout,
+				Out:       o.Out,
+			}
+			if err := waiter.WaitForDeletion(ctx); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+		return utilerrors.NewAggregate(allErrs)
+	}
 	return nil
 }
+
+// findMilvuses gets the named milvus instances or lists the ones matching the selector
+func (o *MilvusDeleteOptions) findMilvuses(c client.Client, ctx context.Context) ([]v1beta1.Milvus, error) {
+	if o.Selector != "" {
+		selector, err := labels.Parse(o.Selector)
+		if err != nil {
+			return nil, err
+		}
+		list := &v1beta1.MilvusList{}
+		if err := c.List(ctx, list, client.InNamespace(o.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+
+	milvuses := []v1beta1.Milvus{}
+	for _, name := range o.Names {
+		var m v1beta1.Milvus
+		namespacedName := types.NamespacedName{
+			Name:      name,
+			Namespace: o.Namespace,
+		}
+		if err := c.Get(ctx, namespacedName, &m); err != nil {
+			if errors.IsNotFound(err) && o.IgnoreNotFound {
+				continue
+			}
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", name, o.Namespace)
+			}
+			return nil, err
+		}
+		milvuses = append(milvuses, m)
+	}
+	return milvuses, nil
+}
+
+// checkProtection refuses to delete protected instances unless --force-unprotected is set
+func (o *MilvusDeleteOptions) checkProtection(milvuses []v1beta1.Milvus) error {
+	protected := []string{}
+	for i := range milvuses {
+		protection := milvus.GetProtection(&milvuses[i])
+		if protection == nil {
+			continue
+		}
+		if o.ForceUnprotected {
+			fmt.Fprintf(o.ErrOut, "Warning: deleting milvus %s which is %s\n", milvuses[i].Name, protection)
+			continue
+		}
+		protected = append(protected, fmt.Sprintf("milvus %s is %s", milvuses[i].Name, protection))
+	}
+	if len(protected) != 0 {
+		return fmt.Errorf("%s\nrun 'milvusctl unprotect NAME' or pass --force-unprotected to delete anyway", strings.Join(protected, "\n"))
+	}
+	return nil
+}
+
+// pvcsToDelete lists the volumes of the in-cluster dependences whose deletion policy
+// deletes them with the instance, as dependence/pvc
+func (o *MilvusDeleteOptions) pvcsToDelete(c client.Client, ctx context.Context, m *v1beta1.Milvus) ([]string, error) {
+	pvcs := []string{}
+	for _, dependence := range milvus.Dependences {
+		config := milvus.InClusterDependence(&m.Spec, dependence)
+		if config == nil || !o.WithDeletions && !milvus.DeletesPVC(config) {
+			continue
+		}
+		items, err := milvus.ListPVCs(ctx, c, m.Namespace, m.Name, dependence)
+		if err != nil {
+			return nil, err
+		}
+		for _, pvc := range items {
+			pvcs = append(pvcs, dependence+"/"+pvc.Name)
+		}
+	}
+	return pvcs, nil
+}
+
+// confirmDeletion asks before deleting unless --yes or --dry-run is set
+func (o *MilvusDeleteOptions) confirmDeletion(deletions []pendingDeletion) error {
+	if o.Yes || o.DryRunStrategy != cmdutil.DryRunNone {
+		return nil
+	}
+	for _, deletion := range deletions {
+		fmt.Fprintf(o.ErrOut, "milvus %s in namespace %s will be deleted\n", deletion.milvus.Name, deletion.milvus.Namespace)
+		if len(deletion.pvcs) == 0 {
+			fmt.Fprintf(o.ErrOut, "  no persistent volume claims will be deleted\n")
+			continue
+		}
+		fmt.Fprintf(o.ErrOut, "  the following persistent volume claims and their data will be deleted:\n")
+		for _, pvc := range deletion.pvcs {
+			fmt.Fprintf(o.ErrOut, "    %s\n", pvc)
+		}
+	}
+	fmt.Fprintf(o.ErrOut, "continue? [y/N] ")
+	answer, _ := bufio.NewReader(o.In).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("deletion aborted, pass --yes to delete without confirmation")
+}
+
+func (o *MilvusDeleteOptions) deleteMilvus(c client.Client, ctx context.Context, m *v1beta1.Milvus) error {
+	dryRun := []client.UpdateOption{}
+	deleteOpts := []client.DeleteOption{client.PropagationPolicy(o.DeletionOptions.CascadingStrategy)}
+	if o.DeletionOptions.GracePeriod >= 0 {
+		deleteOpts = append(deleteOpts, client.GracePeriodSeconds(int64(o.DeletionOptions.GracePeriod)))
+	}
+	if o.DryRunStrategy == cmdutil.DryRunServer {
+		dryRun = append(dryRun, client.DryRunAll)
+		deleteOpts = append(deleteOpts, client.DryRunAll)
+	}
+
+	if o.WithDeletions {
+		changed := false
+		for _, dependence := range milvus.Dependences {
+			config := milvus.InClusterDependence(&m.Spec, dependence)
+			if config == nil || milvus.DeletesPVC(config) {
+				continue
+			}
+			config.PVCDeletion = true
+			config.DeletionPolicy = v1beta1.DeletionPolicyDelete
+			changed = true
+		}
+		if changed && o.DryRunStrategy != cmdutil.DryRunClient {
+			if err := c.Update(ctx, m, dryRun...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		if err := c.Delete(ctx, m, deleteOpts...); err != nil {
+			if errors.IsNotFound(err) && o.IgnoreNotFound {
+				return nil
+			}
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "milvus.milvus.io \"%s\" deleted%s\n", m.Name, dryRunSuffix(o.DryRunStrategy))
+	return nil
+}
+
+func dryRunSuffix(strategy cmdutil.DryRunStrategy) string {
+	switch strategy {
+	case cmdutil.DryRunClient:
+		return " (dry run)"
+	case cmdutil.DryRunServer:
+		return " (server dry run)"
+	}
+	return ""
+}
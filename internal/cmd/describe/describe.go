@@ -1,14 +1,11 @@
 package describe
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
 	"github.com/spf13/cobra"
-	appsv1 "k8s.io/api/apps/v1"
-	batchv1 "k8s.io/api/batch/v1"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"io"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -19,8 +16,9 @@ import (
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	conclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 	"strings"
+	"time"
 )
 
 var (
@@ -46,7 +44,13 @@ var (
 		milvusctl describe po -l name=myLabel
 		# Describe all pods managed by the 'frontend' replication controller (rc-created pods
 		# get the name of the rc as a prefix in the pod the name)
-		milvusctl describe pods frontend`))
+		milvusctl describe pods frontend
+		# Describe the status of the milvus instance my-release, its components and dependences
+		milvusctl describe milvus my-release --component all --dependence all
+		# Print the same status as one json document
+		milvusctl describe milvus my-release --component all --dependence all -o json
+		# Describe the querynodes of my-release with the events of the last hour
+		milvusctl describe milvus my-release --component querynode --since 1h`))
 )
 
 type MilvusDescribeOptions struct {
@@ -54,7 +58,12 @@ type MilvusDescribeOptions struct {
 	InstanceName    string
 	Component       string
 	Dependence      string
+	Output          string
+	Verbose         bool
+	Since           time.Duration
 	DescribeOptions *kubectldescribe.DescribeOptions
+
+	genericclioptions.IOStreams
 }
 
 func NewLogsDescribe(parent string, f cmdutil.Factory, streams genericclioptions.IOStreams) *kubectldescribe.DescribeOptions {
@@ -74,6 +83,7 @@ func NewMilvusDescribeOptions(f cmdutil.Factory, streams genericclioptions.IOStr
 	return &MilvusDescribeOptions{
 		Namespace:       "default",
 		DescribeOptions: NewLogsDescribe("milvusctl", f, streams),
+		IOStreams:       streams,
 	}
 }
 
@@ -109,6 +119,9 @@ func NewMilvusDescribeCmd(f cmdutil.Factory, streams genericclioptions.IOStreams
 
 	cmd.Flags().StringVar(&o.Component, "component", o.Component, "specify milvus componenet")
 	cmd.Flags().StringVar(&o.Dependence, "dependence", o.Dependence, "specify milvus dependence")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "Output format of describe milvus with --component or --dependence. One of: json|yaml")
+	cmd.Flags().DurationVar(&o.Since, "since", o.Since, "Only show the events of describe milvus newer than a relative duration like 5s, 2m, or 3h. Defaults to all events")
+	cmd.Flags().BoolVar(&o.Verbose, "verbose", o.Verbose, "If true, describe every container of the pods of milvus with --component or --dependence instead of a line per pod")
 	// cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "use type parameter to choose namespace")
 
 	return cmd
@@ -118,411 +131,136 @@ func (o MilvusDescribeOptions) Complete(args []string) error {
 	if len(args) != 2 {
 		return fmt.Errorf("Need two parameters for describe Milvus status: milvus, instance_name")
 	}
+	switch o.Output {
+	case "", "json", "yaml":
+	default:
+		return fmt.Errorf("output format error: %s. choose one of them: json, yaml", o.Output)
+	}
 	return nil
 }
 
 func (o MilvusDescribeOptions) Run(f cmdutil.Factory, client *client.Client, args []string) error {
 	var err error
-	allErrs := []error{}
 	o.InstanceName = args[1]
 	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
-	// fmt.Println(o.Namespace)
-	err = o.MilvusComponent(f, *client)
 	if err != nil {
-		allErrs = append(allErrs, err)
+		return err
 	}
-	err = o.MilvusDependence(f, *client)
+
+	report, err := o.Report(*client, o.DescribeOptions.DescriberSettings.ShowEvents)
+	allErrs := []error{}
 	if err != nil {
 		allErrs = append(allErrs, err)
 	}
-	return utilerrors.NewAggregate(allErrs)
-}
 
-func (o MilvusDescribeOptions) MilvusComponent(f cmdutil.Factory, client client.Client) error {
-	var err error
-	switch o.Component {
-	case "proxy":
-		err = o.DescribeMilvusComponent(f, client, "proxy")
-	case "rootcoord":
-		err = o.DescribeMilvusComponent(f, client, "rootcoord")
-	case "mixcoord":
-		err = o.DescribeMilvusComponent(f, client, "mixcoord")
-	case "indexcoord":
-		err = o.DescribeMilvusComponent(f, client, "indexcoord")
-	case "querycoord":
-		err = o.DescribeMilvusComponent(f, client, "querycoord")
-	case "datacoord":
-		err = o.DescribeMilvusComponent(f, client, "datacoord")
-	case "indexnode":
-		err = o.DescribeMilvusComponent(f, client, "indexnode")
-	case "querynode":
-		err = o.DescribeMilvusComponent(f, client, "querynode")
-	case "datanode":
-		err = o.DescribeMilvusComponent(f, client, "datanode")
-	case "all":
-		err = PrintMilvusStatus(client, o.Namespace, o.InstanceName)
-	case "":
+	switch o.Output {
+	case "json":
+		err = printReport(o.Out, report, json.MarshalIndent)
+	case "yaml":
+		err = printReport(o.Out, report, func(v interface{}, _, _ string) ([]byte, error) { return yaml.Marshal(v) })
 	default:
-		return fmt.Errorf("component parameter error: %s. choose one of them: proxy, rootcoord, mixcoord, indexcoord, querycoord, datacoord, indexnode, querynode, datanode, all", o.Component)
+		err = WriteReport(o.Out, report, o.Verbose)
 	}
-	return err
-}
-
-func (o MilvusDescribeOptions) MilvusDependence(f cmdutil.Factory, client client.Client) error {
-	var err error
-	selectorEtcd := "app.kubernetes.io/instance=" + o.InstanceName + "-etcd, app.kubernetes.io/name=etcd"
-	selectorMinio := "release=" + o.InstanceName + "-minio, app=minio"
-	selectorPulsar := "cluster=" + o.InstanceName + "-pulsar, app=pulsar"
-	selectorKafka := "app.kubernetes.io/instance=" + o.InstanceName + "-kafka, app.kubernetes.io/component=kafka"
-	switch o.Dependence {
-	case "":
-	case "etcd":
-		err = o.DescribeMilvusDependence(f, client, "etcd", selectorEtcd)
-	case "minio":
-		err = o.DescribeMilvusDependence(f, client, "minio", selectorMinio)
-	case "pulsar":
-		err = o.DescribePulsar(f, client, selectorPulsar)
-	case "kafka":
-		err = o.DescribeMilvusDependence(f, client, "kafka", selectorKafka)
-	case "all":
-		allErrs := []error{}
-		err = o.DescribeMilvusDependence(f, client, "etcd", selectorEtcd)
-		if err != nil {
-			allErrs = append(allErrs, err)
-		}
-		err = o.DescribeMilvusDependence(f, client, "minio", selectorMinio)
-		if err != nil {
-			allErrs = append(allErrs, err)
-		}
-		err = o.DescribeMilvusDependence(f, client, "kafka", selectorKafka)
-		if err != nil {
-			allErrs = append(allErrs, err)
-		}
-		err = o.DescribePulsar(f, client, selectorPulsar)
-		if err != nil {
-			allErrs = append(allErrs, err)
-		}
-		return utilerrors.NewAggregate(allErrs)
-	default:
-		return fmt.Errorf("dependence parameter error: %s. choose one of them: etcd, minio, pulsar, kafka, all", o.Dependence)
-	}
-	return err
-}
-
-func (o MilvusDescribeOptions) DescribeMilvusComponent(f cmdutil.Factory, client client.Client, component string) error {
-	var err error
-	allErrs := []error{}
-	selector := "app.kubernetes.io/name=milvus, app.kubernetes.io/instance=" + o.InstanceName + ", app.kubernetes.io/component=" + component
-	infos, err := o.GetResource(f, []string{"deployment"}, selector)
-
 	if err != nil {
-		return err
-	}
-	if len(infos) == 0 {
-		return nil
-	}
-
-	fmt.Printf("Milvus Component Status (%s): \n", component)
-	for _, info := range infos {
-		err = PrintDeploymentStatus(client, info)
-		if err != nil {
-			allErrs = append(allErrs, err)
-		}
-		podSelector := "app.kubernetes.io/name=milvus, app.kubernetes.io/instance=" + o.InstanceName + ", app.kubernetes.io/component=" + component
-		podInfos, err := o.GetResource(f, []string{"pod"}, podSelector)
-		if err != nil {
-			allErrs = append(allErrs, err)
-			return utilerrors.NewAggregate(allErrs)
-		}
-		if len(podInfos) == 0 {
-			// fmt.Println("No pod for Milvus ", component)
-			continue
-		}
-		fmt.Println("Pod Status:")
-		for _, info := range podInfos {
-			err = PrintPodStatus(client, info)
-			if err != nil {
-				allErrs = append(allErrs, err)
-			}
-		}
-		fmt.Println("")
+		allErrs = append(allErrs, err)
 	}
 	return utilerrors.NewAggregate(allErrs)
 }
 
-func (o MilvusDescribeOptions) DescribeMilvusDependence(f cmdutil.Factory, client client.Client, dependence string, selector string) error {
-	var err error
+// Report collects the status of the --component and the --dependence, and their events when showEvents is set,
+// the report holds whatever was collected when an error is returned
+func (o MilvusDescribeOptions) Report(client client.Client, showEvents bool) (*MilvusReport, error) {
+	report := &MilvusReport{Name: o.InstanceName, Namespace: o.Namespace}
 	allErrs := []error{}
-	infos, err := o.GetResource(f, []string{"statefulset"}, selector)
-	if err != nil {
-		return err
-	}
-	if len(infos) == 0 {
-		return nil
-	}
-	fmt.Printf("Milvus Dependence Status (%s): \n", dependence)
-	err = PrintStatefulSetStatus(client, infos[0])
-	if err != nil {
+	if err := o.MilvusComponent(client, report); err != nil {
 		allErrs = append(allErrs, err)
 	}
-	podInfos, err := o.GetResource(f, []string{"pod"}, selector)
-	if err != nil {
+	if err := o.MilvusDependence(client, report); err != nil {
 		allErrs = append(allErrs, err)
-		return utilerrors.NewAggregate(allErrs)
 	}
-	if len(podInfos) == 0 {
-		// fmt.Println("No pod found for ", dependence)
-		return nil
-	}
-	fmt.Println("Pod Status:")
-	for _, info := range podInfos {
-		err = PrintPodStatus(client, info)
-		if err != nil {
+	if showEvents {
+		if err := o.addEvents(client, report); err != nil {
 			allErrs = append(allErrs, err)
 		}
 	}
-	return utilerrors.NewAggregate(allErrs)
+	return report, utilerrors.NewAggregate(allErrs)
 }
 
-func (o MilvusDescribeOptions) DescribePulsar(f cmdutil.Factory, client client.Client, selector string) error {
-	var err error
-	allErrs := []error{}
-	infos, err := o.GetResource(f, []string{"statefulset"}, selector)
-	if err != nil {
-		return err
-	}
-	jobInfos, err := o.GetResource(f, []string{"job"}, selector)
-	if err != nil {
-		return err
-	}
-	if len(infos) == 0 && len(jobInfos) == 0 {
+// MilvusComponent adds the status of the --component to the report, all adds the status
+// of the milvus custom resource and of every component
+func (o MilvusDescribeOptions) MilvusComponent(client client.Client, report *MilvusReport) error {
+	components := []string{}
+	switch {
+	case o.Component == "":
 		return nil
-	}
-
-	fmt.Println("Milvus Dependence Status (pulsar):")
-	for _, info := range infos {
-		err = PrintStatefulSetStatus(client, info)
+	case o.Component == "all":
+		status, err := o.milvusStatus(client)
 		if err != nil {
-			allErrs = append(allErrs, err)
-		}
-		getPodCom := strings.Split(info.Name, "-")
-		podCom := getPodCom[len(getPodCom)-1]
-		podSelector := selector + ", component=" + podCom
-		podInfos, err := o.GetResource(f, []string{"pod"}, podSelector)
-		if err != nil {
-			allErrs = append(allErrs, err)
-			continue
-		}
-		if len(podInfos) == 0 {
-			// fmt.Println("No pod for Milvus ", podCom)
-			continue
+			return err
 		}
-		fmt.Println("Pod Status:")
-		for _, info := range podInfos {
-			err = PrintPodStatus(client, info)
-			if err != nil {
-				allErrs = append(allErrs, err)
-			}
-		}
-		fmt.Println("")
+		report.Status = status
+		components = milvus.Components
+	case milvus.IsComponent(o.Component):
+		components = []string{o.Component}
+	default:
+		return fmt.Errorf("component parameter error: %s. choose one of them: %s, all", o.Component, strings.Join(milvus.Components, ", "))
 	}
 
-	for _, info := range jobInfos {
-		err = PrintJobStatus(client, info)
-		if err != nil {
-			allErrs = append(allErrs, err)
-		}
-		getPodCom := strings.Split(info.Name, "-")
-		podCom := getPodCom[len(getPodCom)-2] + "-init"
-		podSelector := selector + ", component=" + podCom
-		podInfos, err := o.GetResource(f, []string{"pod"}, podSelector)
+	allErrs := []error{}
+	for _, component := range components {
+		status, err := o.componentStatus(client, component)
 		if err != nil {
 			allErrs = append(allErrs, err)
-			continue
-			// return utilerrors.NewAggregate(allErrs)
-		}
-		if len(podInfos) == 0 {
-			fmt.Println("")
-			continue
 		}
-
-		fmt.Println("Pod Status:")
-		for _, info := range podInfos {
-			err = PrintPodStatus(client, info)
-			if err != nil {
-				allErrs = append(allErrs, err)
-			}
+		if status != nil {
+			report.Components = append(report.Components, *status)
 		}
-		fmt.Println("")
 	}
 	return utilerrors.NewAggregate(allErrs)
 }
 
-func (o MilvusDescribeOptions) GetResource(f cmdutil.Factory, labels []string, selector string) ([]*resource.Info, error) {
-	r := f.NewBuilder().
-		Unstructured().
-		ContinueOnError().
-		NamespaceParam(o.Namespace).
-		LabelSelectorParam(selector).
-		ResourceTypeOrNameArgs(true, labels...).
-		RequestChunksOf(o.DescribeOptions.DescriberSettings.ChunkSize).
-		Flatten().
-		Do()
-	if err := r.Err(); err != nil {
-		return nil, err
-	}
-	infos, err := r.Infos()
-	return infos, err
-}
-
-func PrintPodStatus(client client.Client, info *resource.Info) error {
-	pod := &corev1.Pod{}
-	err := client.Get(context.Background(), conclient.ObjectKey{
-		Namespace: info.Namespace,
-		Name:      info.Name,
-	}, pod)
-
-	if errors.IsNotFound(err) {
-		return err
-	}
-
-	fmt.Println("  Name:           ", info.Name)
-	fmt.Println("  Status:         ", pod.Status.Phase)
-	fmt.Println("  Conditions:")
-	fmt.Println("    Type:         ", pod.Status.Conditions[0].Type)
-	fmt.Println("    Status:       ", pod.Status.Conditions[0].Status)
-	fmt.Println("  Containers:")
-	fmt.Println("    Name:         ", pod.Status.ContainerStatuses[0].Name)
-	// fmt.Println("    State:        ", pod.Status.ContainerStatuses[0].State)
-	fmt.Println("    Ready:        ", pod.Status.ContainerStatuses[0].Ready)
-	fmt.Println("    RestartCount: ", pod.Status.ContainerStatuses[0].RestartCount)
-	fmt.Println("    Started:      ", *pod.Status.ContainerStatuses[0].Started)
-	fmt.Println("")
-
-	return nil
-}
-
-func PrintDeploymentStatus(client client.Client, info *resource.Info) error {
-	deployment := &appsv1.Deployment{}
-	err := client.Get(context.Background(), conclient.ObjectKey{
-		Namespace: info.Namespace,
-		Name:      info.Name,
-	}, deployment)
-
-	if errors.IsNotFound(err) {
-		return err
-	}
-
-	fmt.Println("Name:             ", info.Name)
-	fmt.Println("Replicas:         ", deployment.Status.Replicas, " desired | ", deployment.Status.AvailableReplicas, " available | ", deployment.Status.UnavailableReplicas, " unavailable")
-	if len(deployment.Status.Conditions) != 0 {
-		fmt.Println("Conditions:     ")
-		fmt.Println("  Type:         ", deployment.Status.Conditions[0].Type)
-		fmt.Println("  Status:       ", deployment.Status.Conditions[0].Status)
-	}
-
-	return nil
-}
-
-func PrintStatefulSetStatus(client client.Client, info *resource.Info) error {
-	statefulSet := &appsv1.StatefulSet{}
-
-	err := client.Get(context.Background(), conclient.ObjectKey{
-		Namespace: info.Namespace,
-		Name:      info.Name,
-	}, statefulSet)
-
-	if errors.IsNotFound(err) {
-		return err
+// MilvusDependence adds the status of the --dependence to the report
+func (o MilvusDescribeOptions) MilvusDependence(client client.Client, report *MilvusReport) error {
+	dependences := []string{}
+	switch o.Dependence {
+	case "":
+		return nil
+	case "all":
+		dependences = milvus.Dependences
+	case "etcd", "minio", "pulsar", "kafka":
+		dependences = []string{o.Dependence}
+	default:
+		return fmt.Errorf("dependence parameter error: %s. choose one of them: %s, all", o.Dependence, strings.Join(milvus.Dependences, ", "))
 	}
 
-	fmt.Println("Name:             ", info.Name)
-	fmt.Println("Replicas:         ", statefulSet.Status.Replicas, " desired | ", statefulSet.Status.ReadyReplicas, " ready")
-	if len(statefulSet.Status.Conditions) != 0 {
-		fmt.Println("Conditions:     ")
-		fmt.Println("  Type:         ", statefulSet.Status.Conditions[0].Type)
-		fmt.Println("  Status:       ", statefulSet.Status.Conditions[0].Status)
+	allErrs := []error{}
+	for _, dependence := range dependences {
+		status, err := o.dependenceStatus(client, dependence)
+		if err != nil {
+			allErrs = append(allErrs, err)
+		}
+		if status != nil {
+			report.Dependencies = append(report.Dependencies, *status)
+		}
 	}
-	return nil
+	return utilerrors.NewAggregate(allErrs)
 }
 
-func PrintJobStatus(client client.Client, info *resource.Info) error {
-	job := &batchv1.Job{}
-
-	err := client.Get(context.Background(), conclient.ObjectKey{
-		Namespace: info.Namespace,
-		Name:      info.Name,
-	}, job)
-
-	if errors.IsNotFound(err) {
+// addEvents adds the events of the milvus instance and of the objects of the report
+func (o MilvusDescribeOptions) addEvents(client client.Client, report *MilvusReport) error {
+	objects, err := o.involvedObjects(client, report)
+	if err != nil {
 		return err
 	}
-
-	fmt.Println("Name:             ", info.Name)
-	fmt.Println("Replicas:         ", job.Status.Active, " active | ", job.Status.Succeeded, " succeeded | ", job.Status.Failed, " failed")
-	if len(job.Status.Conditions) != 0 {
-		fmt.Println("Conditions:     ")
-		fmt.Println("  Type:           ", job.Status.Conditions[0].Type)
-		fmt.Println("  Status:         ", job.Status.Conditions[0].Status)
-	}
-	return nil
+	report.Events, err = o.milvusEvents(client, objects, o.Since)
+	return err
 }
 
-func PrintMilvusStatus(client client.Client, namespace string, name string) error {
-	milvus := &v1beta1.Milvus{}
-
-	err := client.Get(context.Background(), conclient.ObjectKey{
-		Namespace: namespace,
-		Name:      name,
-	}, milvus)
-
-	if errors.IsNotFound(err) {
+func printReport(out io.Writer, report *MilvusReport, marshal func(v interface{}, prefix, indent string) ([]byte, error)) error {
+	data, err := marshal(report, "", "    ")
+	if err != nil {
 		return err
 	}
-
-	fmt.Println("Name:             ", name)
-	fmt.Println("Status:           ", milvus.Status.Status)
-	fmt.Println("Endpoint:         ", milvus.Status.Endpoint)
-	if len(milvus.Status.Conditions) != 0 {
-		fmt.Println("Conditions:")
-	}
-	for _, condition := range milvus.Status.Conditions {
-		fmt.Println("  Type:         ", condition.Type)
-		fmt.Println("  Status:       ", condition.Status)
-		fmt.Println("  Message:      ", condition.Message)
-	}
-
-	fmt.Println("Replicas:")
-	replicas := milvus.Status.Replicas
-	if replicas.Proxy != 0 {
-		fmt.Println("  Proxy:        ", replicas.Proxy)
-	}
-	if replicas.MixCoord != 0 {
-		fmt.Println("  MixCoord:     ", replicas.MixCoord)
-	}
-	if replicas.RootCoord != 0 {
-		fmt.Println("  RootCoord:    ", replicas.RootCoord)
-	}
-	if replicas.DataCoord != 0 {
-		fmt.Println("  DataCoord:    ", replicas.DataCoord)
-	}
-	if replicas.IndexCoord != 0 {
-		fmt.Println("  IndexCoord:   ", replicas.IndexCoord)
-	}
-	if replicas.QueryCoord != 0 {
-		fmt.Println("  QueryCoord:   ", replicas.QueryCoord)
-	}
-	if replicas.DataNode != 0 {
-		fmt.Println("  DataNode:     ", replicas.DataNode)
-	}
-	if replicas.IndexNode != 0 {
-		fmt.Println("  IndexNode:    ", replicas.IndexNode)
-	}
-	if replicas.QueryNode != 0 {
-		fmt.Println("  QueryNode:    ", replicas.QueryNode)
-	}
-	if replicas.Standalone != 0 {
-		fmt.Println("  Standalone:   ", replicas.Standalone)
-	}
-	return nil
+	_, err = fmt.Fprintln(out, strings.TrimSuffix(string(data), "\n"))
+	return err
 }
@@ -0,0 +1,148 @@
+package describe
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Event is a kubernetes event of the milvus instance or of one of its objects,
+// the repeated events of an object are merged into one
+type Event struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Object  string `json:"object"`
+	Message string `json:"message"`
+	Count   int32  `json:"count"`
+	// Warning is set for the events of type Warning
+	Warning        bool        `json:"warning"`
+	FirstTimestamp metav1.Time `json:"firstTimestamp"`
+	LastTimestamp  metav1.Time `json:"lastTimestamp"`
+}
+
+// involvedObjects returns the kind/name of the milvus instance and of the objects in the report,
+// together with the persistent volume claims of the described components and dependences
+func (o MilvusDescribeOptions) involvedObjects(c client.Client, report *MilvusReport) (map[string]bool, error) {
+	objects := map[string]bool{"Milvus/" + o.InstanceName: true}
+	addPods := func(pods []PodStatus) {
+		for _, pod := range pods {
+			objects["Pod/"+pod.Name] = true
+		}
+	}
+	for _, component := range report.Components {
+		for _, deployment := range component.Deployments {
+			objects["Deployment/"+deployment.Name] = true
+			addPods(deployment.Pods)
+		}
+	}
+	for _, dependence := range report.Dependencies {
+		for _, statefulSet := range dependence.StatefulSets {
+			objects["StatefulSet/"+statefulSet.Name] = true
+			addPods(statefulSet.Pods)
+		}
+		for _, job := range dependence.Jobs {
+			objects["Job/"+job.Name] = true
+			addPods(job.Pods)
+		}
+	}
+
+	selectors := []string{}
+	if len(report.Components) != 0 {
+		selectors = append(selectors, milvus.InstanceSelector(o.InstanceName))
+	}
+	for _, dependence := range report.Dependencies {
+		selector, _ := milvus.DependenceSelector(o.InstanceName, dependence.Name)
+		selectors = append(selectors, selector)
+	}
+	for _, selector := range selectors {
+		pvcs := &corev1.PersistentVolumeClaimList{}
+		if err := milvus.ListBySelector(context.TODO(), c, o.Namespace, selector, pvcs); err != nil {
+			return nil, err
+		}
+		for _, pvc := range pvcs.Items {
+			objects["PersistentVolumeClaim/"+pvc.Name] = true
+		}
+	}
+	return objects, nil
+}
+
+// milvusEvents returns the merged events of the objects sorted by their last timestamp,
+// the events older than since are dropped when it is set
+func (o MilvusDescribeOptions) milvusEvents(c client.Client, objects map[string]bool, since time.Duration) ([]Event, error) {
+	events := &corev1.EventList{}
+	if err := c.List(context.TODO(), events, client.InNamespace(o.Namespace)); err != nil {
+		return nil, err
+	}
+
+	merged := map[string]*Event{}
+	keys := []string{}
+	for _, event := range events.Items {
+		object := event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name
+		if !objects[object] {
+			continue
+		}
+		first, last := eventTimes(&event)
+		if since > 0 && last.Time.Before(time.Now().Add(-since)) {
+			continue
+		}
+		count := event.Count
+		if count == 0 {
+			count = 1
+		}
+		key := object + "\x00" + event.Type + "\x00" + event.Reason + "\x00" + event.Message
+		if e, ok := merged[key]; ok {
+			e.Count += count
+			if first.Before(&e.FirstTimestamp) {
+				e.FirstTimestamp = first
+			}
+			if e.LastTimestamp.Before(&last) {
+				e.LastTimestamp = last
+			}
+			continue
+		}
+		merged[key] = &Event{
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Object:         object,
+			Message:        event.Message,
+			Count:          count,
+			Warning:        event.Type == corev1.EventTypeWarning,
+			FirstTimestamp: first,
+			LastTimestamp:  last,
+		}
+		keys = append(keys, key)
+	}
+
+	result := []Event{}
+	for _, key := range keys {
+		result = append(result, *merged[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastTimestamp.Before(&result[j].LastTimestamp)
+	})
+	return result, nil
+}
+
+// eventTimes returns the first and last time of the event, the events.k8s.io api
+// only fills the event time and the series
+func eventTimes(event *corev1.Event) (metav1.Time, metav1.Time) {
+	first, last := event.FirstTimestamp, event.LastTimestamp
+	if first.IsZero() {
+		first = metav1.NewTime(event.EventTime.Time)
+	}
+	if first.IsZero() {
+		first = event.CreationTimestamp
+	}
+	if event.Series != nil && !event.Series.LastObservedTime.IsZero() {
+		last = metav1.NewTime(event.Series.LastObservedTime.Time)
+	}
+	if last.IsZero() {
+		last = first
+	}
+	return first, last
+}
@@ -0,0 +1,142 @@
+package describe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodStatus is the status of a pod and of all its containers
+type PodStatus struct {
+	Name  string          `json:"name"`
+	Phase corev1.PodPhase `json:"phase"`
+	// Reason summarizes the pod like kubectl get pods does, like CrashLoopBackOff or Init:0/1
+	Reason         string            `json:"reason"`
+	Node           string            `json:"node,omitempty"`
+	ReadyCount     int               `json:"readyCount"`
+	ContainerCount int               `json:"containerCount"`
+	Restarts       int32             `json:"restarts"`
+	Conditions     []Condition       `json:"conditions,omitempty"`
+	InitContainers []ContainerStatus `json:"initContainers,omitempty"`
+	Containers     []ContainerStatus `json:"containers,omitempty"`
+}
+
+// ContainerStatus is the status of a container or an init container of a pod
+type ContainerStatus struct {
+	Name         string          `json:"name"`
+	Image        string          `json:"image,omitempty"`
+	Ready        bool            `json:"ready"`
+	RestartCount int32           `json:"restartCount"`
+	Started      *bool           `json:"started,omitempty"`
+	State        ContainerState  `json:"state"`
+	LastState    *ContainerState `json:"lastState,omitempty"`
+}
+
+// ContainerState is the running, waiting or terminated state of a container
+type ContainerState struct {
+	// State is Running, Waiting, Terminated, or Pending while the kubelet has not reported the container
+	State      string       `json:"state"`
+	Reason     string       `json:"reason,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	ExitCode   *int32       `json:"exitCode,omitempty"`
+	Signal     int32        `json:"signal,omitempty"`
+	StartedAt  *metav1.Time `json:"startedAt,omitempty"`
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+}
+
+func (s ContainerState) String() string {
+	details := []string{}
+	if s.Reason != "" {
+		details = append(details, s.Reason)
+	}
+	if s.ExitCode != nil {
+		details = append(details, fmt.Sprintf("exit code %d", *s.ExitCode))
+	}
+	if s.Signal != 0 {
+		details = append(details, fmt.Sprintf("signal %d", s.Signal))
+	}
+	if len(details) == 0 {
+		return s.State
+	}
+	return s.State + " (" + strings.Join(details, ", ") + ")"
+}
+
+// newPodStatus converts the pod, the containers the kubelet has not reported yet are Pending
+func newPodStatus(pod *corev1.Pod) PodStatus {
+	status := PodStatus{
+		Name:           pod.Name,
+		Phase:          pod.Status.Phase,
+		Node:           pod.Spec.NodeName,
+		ContainerCount: len(pod.Spec.Containers),
+	}
+	for _, condition := range pod.Status.Conditions {
+		status.Conditions = append(status.Conditions, Condition{Type: string(condition.Type), Status: string(condition.Status), Reason: condition.Reason, Message: condition.Message})
+	}
+	status.InitContainers = containerStatuses(pod.Spec.InitContainers, pod.Status.InitContainerStatuses)
+	status.Containers = containerStatuses(pod.Spec.Containers, pod.Status.ContainerStatuses)
+	for _, container := range status.InitContainers {
+		status.Restarts += container.RestartCount
+	}
+	for _, container := range status.Containers {
+		status.Restarts += container.RestartCount
+		if container.Ready {
+			status.ReadyCount++
+		}
+	}
+	status.Reason = milvus.PodReason(pod)
+	return status
+}
+
+func containerStatuses(containers []corev1.Container, statuses []corev1.ContainerStatus) []ContainerStatus {
+	byName := map[string]corev1.ContainerStatus{}
+	for _, status := range statuses {
+		byName[status.Name] = status
+	}
+	result := []ContainerStatus{}
+	for _, container := range containers {
+		converted := ContainerStatus{Name: container.Name, Image: container.Image, State: ContainerState{State: "Pending"}}
+		if status, ok := byName[container.Name]; ok {
+			converted.Ready = status.Ready
+			converted.RestartCount = status.RestartCount
+			converted.Started = status.Started
+			converted.State = containerState(status.State)
+			if status.LastTerminationState.Terminated != nil {
+				lastState := containerState(status.LastTerminationState)
+				converted.LastState = &lastState
+			}
+		}
+		result = append(result, converted)
+	}
+	return result
+}
+
+func containerState(state corev1.ContainerState) ContainerState {
+	switch {
+	case state.Running != nil:
+		return ContainerState{State: "Running", StartedAt: timeOrNil(state.Running.StartedAt)}
+	case state.Waiting != nil:
+		return ContainerState{State: "Waiting", Reason: state.Waiting.Reason, Message: state.Waiting.Message}
+	case state.Terminated != nil:
+		exitCode := state.Terminated.ExitCode
+		return ContainerState{
+			State:      "Terminated",
+			Reason:     state.Terminated.Reason,
+			Message:    state.Terminated.Message,
+			ExitCode:   &exitCode,
+			Signal:     state.Terminated.Signal,
+			StartedAt:  timeOrNil(state.Terminated.StartedAt),
+			FinishedAt: timeOrNil(state.Terminated.FinishedAt),
+		}
+	}
+	return ContainerState{State: "Pending"}
+}
+
+func timeOrNil(t metav1.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
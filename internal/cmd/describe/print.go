@@ -0,0 +1,261 @@
+package describe
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// WriteReport renders the human readable view of the report, the pods are printed as a table
+// unless verbose is set
+func WriteReport(out io.Writer, report *MilvusReport, verbose bool) error {
+	w := &errWriter{out: out}
+	if report.Status != nil {
+		writeMilvusStatus(w, report.Name, report.Status)
+		w.println("")
+	}
+	for _, component := range report.Components {
+		w.printf("Milvus Component Status (%s): \n", component.Name)
+		for _, deployment := range component.Deployments {
+			w.println("Name:             ", deployment.Name)
+			w.println("Replicas:         ", deployment.Replicas, " desired | ", deployment.AvailableReplicas, " available | ", deployment.UnavailableReplicas, " unavailable")
+			writeConditions(w, "", deployment.Conditions, verbose)
+			writePods(w, deployment.Pods, verbose)
+			w.println("")
+		}
+	}
+	for _, dependence := range report.Dependencies {
+		w.printf("Milvus Dependence Status (%s): \n", dependence.Name)
+		for _, statefulSet := range dependence.StatefulSets {
+			w.println("Name:             ", statefulSet.Name)
+			w.println("Replicas:         ", statefulSet.Replicas, " desired | ", statefulSet.ReadyReplicas, " ready")
+			writeConditions(w, "", statefulSet.Conditions, verbose)
+			writePods(w, statefulSet.Pods, verbose)
+			w.println("")
+		}
+		for _, job := range dependence.Jobs {
+			w.println("Name:             ", job.Name)
+			w.println("Replicas:         ", job.Active, " active | ", job.Succeeded, " succeeded | ", job.Failed, " failed")
+			writeConditions(w, "", job.Conditions, verbose)
+			writePods(w, job.Pods, verbose)
+			w.println("")
+		}
+	}
+	writeEvents(w, report.Events)
+	return w.err
+}
+
+// writeEvents prints the events oldest first, the warnings are counted in the header
+func writeEvents(w *errWriter, events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	warnings := 0
+	for _, event := range events {
+		if event.Warning {
+			warnings++
+		}
+	}
+	switch warnings {
+	case 0:
+		w.println("Events:")
+	case 1:
+		w.println("Events (1 warning):")
+	default:
+		w.printf("Events (%d warnings):\n", warnings)
+	}
+	if w.err != nil {
+		return
+	}
+	tw := printers.GetNewTabWriter(w.out)
+	fmt.Fprintln(tw, "  TYPE\tREASON\tAGE\tOBJECT\tMESSAGE")
+	for _, event := range events {
+		age := translateTimestampSince(event.LastTimestamp)
+		if event.Count > 1 {
+			age = fmt.Sprintf("%s (x%d over %s)", age, event.Count, translateTimestampSince(event.FirstTimestamp))
+		}
+		eventType := event.Type
+		if event.Warning {
+			eventType = "!" + eventType
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", eventType, event.Reason, age, event.Object, strings.TrimSpace(event.Message))
+	}
+	w.err = tw.Flush()
+}
+
+func translateTimestampSince(timestamp metav1.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(timestamp.Time))
+}
+
+func writeMilvusStatus(w *errWriter, name string, status *v1beta1.MilvusStatus) {
+	w.println("Name:             ", name)
+	w.println("Status:           ", status.Status)
+	w.println("Endpoint:         ", status.Endpoint)
+	if len(status.Conditions) != 0 {
+		w.println("Conditions:")
+	}
+	for _, condition := range status.Conditions {
+		w.println("  Type:         ", condition.Type)
+		w.println("  Status:       ", condition.Status)
+		w.println("  Message:      ", condition.Message)
+	}
+
+	w.println("Replicas:")
+	replicas := status.Replicas
+	for _, replica := range []struct {
+		label string
+		count int
+	}{
+		{"  Proxy:        ", replicas.Proxy},
+		{"  MixCoord:     ", replicas.MixCoord},
+		{"  RootCoord:    ", replicas.RootCoord},
+		{"  DataCoord:    ", replicas.DataCoord},
+		{"  IndexCoord:   ", replicas.IndexCoord},
+		{"  QueryCoord:   ", replicas.QueryCoord},
+		{"  DataNode:     ", replicas.DataNode},
+		{"  IndexNode:    ", replicas.IndexNode},
+		{"  QueryNode:    ", replicas.QueryNode},
+		{"  Standalone:   ", replicas.Standalone},
+	} {
+		if replica.count != 0 {
+			w.println(replica.label, replica.count)
+		}
+	}
+}
+
+func writeConditions(w *errWriter, indent string, conditions []Condition, verbose bool) {
+	if len(conditions) == 0 {
+		return
+	}
+	w.println(indent + "Conditions:     ")
+	for _, condition := range conditions {
+		w.println(indent+"  Type:         ", condition.Type)
+		w.println(indent+"  Status:       ", condition.Status)
+		if verbose && condition.Reason != "" {
+			w.println(indent+"  Reason:       ", condition.Reason)
+		}
+		if verbose && condition.Message != "" {
+			w.println(indent+"  Message:      ", condition.Message)
+		}
+	}
+}
+
+func writePods(w *errWriter, pods []PodStatus, verbose bool) {
+	if len(pods) == 0 {
+		return
+	}
+	w.println("Pod Status:")
+	if !verbose {
+		writePodTable(w, pods)
+		return
+	}
+	for _, pod := range pods {
+		w.println("  Name:           ", pod.Name)
+		w.println("  Status:         ", pod.Reason)
+		w.println("  Phase:          ", pod.Phase)
+		w.println("  Node:           ", pod.Node)
+		w.println("  Ready:          ", fmt.Sprintf("%d/%d", pod.ReadyCount, pod.ContainerCount))
+		w.println("  Restarts:       ", pod.Restarts)
+		writeConditions(w, "  ", pod.Conditions, true)
+		if len(pod.InitContainers) != 0 {
+			w.println("  Init Containers:")
+			writeContainers(w, pod.InitContainers)
+		}
+		if len(pod.Containers) != 0 {
+			w.println("  Containers:")
+			writeContainers(w, pod.Containers)
+		}
+		w.println("")
+	}
+}
+
+func writeContainers(w *errWriter, containers []ContainerStatus) {
+	for _, container := range containers {
+		w.println("    Name:         ", container.Name)
+		w.println("    Image:        ", container.Image)
+		w.println("    State:        ", container.State)
+		if container.State.Message != "" {
+			w.println("      Message:    ", container.State.Message)
+		}
+		if container.LastState != nil {
+			last := container.LastState.String()
+			if container.LastState.FinishedAt != nil {
+				last += " at " + container.LastState.FinishedAt.UTC().Format(time.RFC3339)
+			}
+			w.println("    Last State:   ", last)
+		}
+		w.println("    Ready:        ", container.Ready)
+		w.println("    RestartCount: ", container.RestartCount)
+		if container.Started != nil {
+			w.println("    Started:      ", *container.Started)
+		}
+	}
+}
+
+// writePodTable prints a line per pod, with the containers that are not ready and the last termination
+func writePodTable(w *errWriter, pods []PodStatus) {
+	if w.err != nil {
+		return
+	}
+	tw := printers.GetNewTabWriter(w.out)
+	fmt.Fprintln(tw, "  NAME\tREADY\tSTATUS\tRESTARTS\tNODE\tCONTAINERS")
+	for _, pod := range pods {
+		problems := []string{}
+		for _, container := range append(append([]ContainerStatus{}, pod.InitContainers...), pod.Containers...) {
+			problem := ""
+			switch {
+			case container.Ready, container.State.State == "Terminated" && container.State.Reason == "Completed":
+			case container.State.State == "Running":
+				problem = "Running, not ready"
+			default:
+				problem = container.State.String()
+			}
+			if container.LastState != nil && container.LastState.Reason != "" {
+				if problem != "" {
+					problem += ", "
+				}
+				problem += "last " + container.LastState.String()
+			}
+			if problem != "" {
+				problems = append(problems, container.Name+": "+problem)
+			}
+		}
+		node := pod.Node
+		if node == "" {
+			node = "<none>"
+		}
+		containers := "<all ready>"
+		if len(problems) != 0 {
+			containers = strings.Join(problems, "; ")
+		}
+		fmt.Fprintf(tw, "  %s\t%d/%d\t%s\t%d\t%s\t%s\n", pod.Name, pod.ReadyCount, pod.ContainerCount, pod.Reason, pod.Restarts, node, containers)
+	}
+	w.err = tw.Flush()
+}
+
+// errWriter keeps the first write error so that the rendering does not check every line
+type errWriter struct {
+	out io.Writer
+	err error
+}
+
+func (w *errWriter) println(a ...interface{}) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintln(w.out, a...)
+	}
+}
+
+func (w *errWriter) printf(format string, a ...interface{}) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintf(w.out, format, a...)
+	}
+}
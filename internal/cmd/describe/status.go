@@ -0,0 +1,220 @@
+package describe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MilvusReport is the status document of a milvus instance printed by describe milvus,
+// the text view and the -o json|yaml output are rendered from it
+type MilvusReport struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Status is the status of the milvus custom resource, set with --component all
+	Status       *v1beta1.MilvusStatus `json:"status,omitempty"`
+	Components   []ComponentStatus     `json:"components,omitempty"`
+	Dependencies []DependenceStatus    `json:"dependencies,omitempty"`
+	Events       []Event               `json:"events,omitempty"`
+}
+
+// ComponentStatus is the status of the deployments of a milvus component
+type ComponentStatus struct {
+	Name        string           `json:"name"`
+	Deployments []WorkloadStatus `json:"deployments"`
+}
+
+// DependenceStatus is the status of the statefulsets and jobs of an in-cluster dependence
+type DependenceStatus struct {
+	Name         string           `json:"name"`
+	StatefulSets []WorkloadStatus `json:"statefulSets"`
+	Jobs         []JobStatus      `json:"jobs,omitempty"`
+}
+
+// WorkloadStatus is the status of a deployment or a statefulset and of its pods
+type WorkloadStatus struct {
+	Name                string      `json:"name"`
+	Replicas            int32       `json:"replicas"`
+	ReadyReplicas       int32       `json:"readyReplicas"`
+	AvailableReplicas   int32       `json:"availableReplicas"`
+	UnavailableReplicas int32       `json:"unavailableReplicas"`
+	Conditions          []Condition `json:"conditions,omitempty"`
+	Pods                []PodStatus `json:"pods"`
+}
+
+// JobStatus is the status of a job, like the pulsar init jobs, and of its pods
+type JobStatus struct {
+	Name       string      `json:"name"`
+	Active     int32       `json:"active"`
+	Succeeded  int32       `json:"succeeded"`
+	Failed     int32       `json:"failed"`
+	Conditions []Condition `json:"conditions,omitempty"`
+	Pods       []PodStatus `json:"pods"`
+}
+
+// Condition is a condition of a pod or a workload
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// componentStatus collects the deployments of a milvus component, it is nil when there is none
+func (o MilvusDescribeOptions) componentStatus(client client.Client, component string) (*ComponentStatus, error) {
+	ctx := context.TODO()
+	selector := milvus.ComponentSelector(o.InstanceName, component)
+	deployments := &appsv1.DeploymentList{}
+	if err := milvus.ListBySelector(ctx, client, o.Namespace, selector, deployments); err != nil {
+		return nil, err
+	}
+	if len(deployments.Items) == 0 {
+		return nil, nil
+	}
+
+	status := &ComponentStatus{Name: component, Deployments: []WorkloadStatus{}}
+	allErrs := []error{}
+	for _, deployment := range deployments.Items {
+		// each deployment has only the pods of its own selector, a component may run several deployments
+		podSelector := selector
+		if deployment.Spec.Selector != nil {
+			s, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+			if err != nil {
+				allErrs = append(allErrs, err)
+				continue
+			}
+			podSelector = s.String()
+		}
+		pods, err := o.podStatuses(client, podSelector)
+		if err != nil {
+			allErrs = append(allErrs, err)
+		}
+		conditions := []Condition{}
+		for _, condition := range deployment.Status.Conditions {
+			conditions = append(conditions, Condition{Type: string(condition.Type), Status: string(condition.Status), Reason: condition.Reason, Message: condition.Message})
+		}
+		status.Deployments = append(status.Deployments, WorkloadStatus{
+			Name:                deployment.Name,
+			Replicas:            deployment.Status.Replicas,
+			ReadyReplicas:       deployment.Status.ReadyReplicas,
+			AvailableReplicas:   deployment.Status.AvailableReplicas,
+			UnavailableReplicas: deployment.Status.UnavailableReplicas,
+			Conditions:          conditions,
+			Pods:                pods,
+		})
+	}
+	return status, utilerrors.NewAggregate(allErrs)
+}
+
+// dependenceStatus collects the statefulsets and the jobs of a dependence, it is nil when there is none
+func (o MilvusDescribeOptions) dependenceStatus(client client.Client, dependence string) (*DependenceStatus, error) {
+	ctx := context.TODO()
+	selector, err := milvus.DependenceSelector(o.InstanceName, dependence)
+	if err != nil {
+		return nil, err
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := milvus.ListBySelector(ctx, client, o.Namespace, selector, statefulSets); err != nil {
+		return nil, err
+	}
+	jobs := &batchv1.JobList{}
+	if err := milvus.ListBySelector(ctx, client, o.Namespace, selector, jobs); err != nil {
+		return nil, err
+	}
+	if len(statefulSets.Items) == 0 && len(jobs.Items) == 0 {
+		return nil, nil
+	}
+
+	status := &DependenceStatus{Name: dependence, StatefulSets: []WorkloadStatus{}}
+	allErrs := []error{}
+	for _, statefulSet := range statefulSets.Items {
+		podSelector := selector
+		if dependence == "pulsar" {
+			// pulsar runs one statefulset per component, named <release>-pulsar-<component>
+			parts := strings.Split(statefulSet.Name, "-")
+			podSelector = selector + ", component=" + parts[len(parts)-1]
+		}
+		pods, err := o.podStatuses(client, podSelector)
+		if err != nil {
+			allErrs = append(allErrs, err)
+		}
+		conditions := []Condition{}
+		for _, condition := range statefulSet.Status.Conditions {
+			conditions = append(conditions, Condition{Type: string(condition.Type), Status: string(condition.Status), Reason: condition.Reason, Message: condition.Message})
+		}
+		status.StatefulSets = append(status.StatefulSets, WorkloadStatus{
+			Name:                statefulSet.Name,
+			Replicas:            statefulSet.Status.Replicas,
+			ReadyReplicas:       statefulSet.Status.ReadyReplicas,
+			AvailableReplicas:   statefulSet.Status.AvailableReplicas,
+			UnavailableReplicas: statefulSet.Status.Replicas - statefulSet.Status.AvailableReplicas,
+			Conditions:          conditions,
+			Pods:                pods,
+		})
+	}
+	for _, job := range jobs.Items {
+		// the pulsar init jobs are named <release>-pulsar-<component>-init, their pods are labeled component=<component>-init
+		parts := strings.Split(job.Name, "-")
+		podSelector := selector
+		if len(parts) >= 2 {
+			podSelector = selector + ", component=" + parts[len(parts)-2] + "-init"
+		}
+		pods, err := o.podStatuses(client, podSelector)
+		if err != nil {
+			allErrs = append(allErrs, err)
+		}
+		conditions := []Condition{}
+		for _, condition := range job.Status.Conditions {
+			conditions = append(conditions, Condition{Type: string(condition.Type), Status: string(condition.Status), Reason: condition.Reason, Message: condition.Message})
+		}
+		status.Jobs = append(status.Jobs, JobStatus{
+			Name:       job.Name,
+			Active:     job.Status.Active,
+			Succeeded:  job.Status.Succeeded,
+			Failed:     job.Status.Failed,
+			Conditions: conditions,
+			Pods:       pods,
+		})
+	}
+	return status, utilerrors.NewAggregate(allErrs)
+}
+
+func (o MilvusDescribeOptions) podStatuses(client client.Client, selector string) ([]PodStatus, error) {
+	pods := &corev1.PodList{}
+	if err := milvus.ListBySelector(context.TODO(), client, o.Namespace, selector, pods); err != nil {
+		return nil, err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	statuses := []PodStatus{}
+	for i := range pods.Items {
+		statuses = append(statuses, newPodStatus(&pods.Items[i]))
+	}
+	return statuses, nil
+}
+
+// milvusStatus returns the status of the milvus custom resource
+func (o MilvusDescribeOptions) milvusStatus(client client.Client) (*v1beta1.MilvusStatus, error) {
+	m := &v1beta1.Milvus{}
+	err := client.Get(context.TODO(), types.NamespacedName{Namespace: o.Namespace, Name: o.InstanceName}, m)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", o.InstanceName, o.Namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m.Status, nil
+}
@@ -0,0 +1,412 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kubectlexec "k8s.io/kubectl/pkg/cmd/exec"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	ActionStatus         = "status"
+	ActionEndpointHealth = "endpoint-health"
+	ActionDefrag         = "defrag"
+	ActionCompact        = "compact"
+	ActionAlarmList      = "alarm list"
+	ActionAlarmDisarm    = "alarm disarm"
+	ActionSnapshotSave   = "snapshot save"
+
+	defaultPodExecTimeout = 60 * time.Second
+	// snapshotPath is where the snapshot is saved in the etcd pod before it is copied
+	snapshotPath = "/tmp/milvusctl-snapshot.db"
+	// healthInterval is the interval of the health checks while waiting for a defragmented member
+	healthInterval = 2 * time.Second
+)
+
+// Actions are the etcdctl commands run by milvusctl etcd
+var Actions = []string{ActionStatus, ActionEndpointHealth, ActionDefrag, ActionCompact, ActionAlarmList, ActionAlarmDisarm, ActionSnapshotSave}
+
+var (
+	etcdLong = templates.LongDesc(i18n.T(`
+		Run etcdctl maintenance commands against the in-cluster etcd holding the metadata of a milvus instance.
+		The etcd pods are selected with the app.kubernetes.io/instance=NAME-etcd label and etcdctl is
+		run in them through exec, against their local member. The output is printed as a table.
+
+		status and endpoint-health query every member. defrag defragments one member at a time, the
+		followers first and the leader last, and waits for every member to be healthy before the next
+		one. compact compacts the history up to the current revision, or up to --revision. alarm list
+		and alarm disarm list and disarm the alarms of the cluster, like NOSPACE. snapshot save saves a
+		snapshot of a healthy member to FILE, NAME-etcd-snapshot-<timestamp>.db by default.`))
+
+	etcdExample = templates.Examples(i18n.T(`
+		# Show the status of the etcd members of milvus my-release
+		milvusctl etcd my-release status
+		# Check the health of the etcd members of milvus my-release
+		milvusctl etcd my-release endpoint-health
+		# Defragment the etcd members of milvus my-release one at a time
+		milvusctl etcd my-release defrag
+		# Compact the history of the etcd of milvus my-release up to the current revision
+		milvusctl etcd my-release compact
+		# List and disarm the alarms, like NOSPACE after the etcd quota was exceeded
+		milvusctl etcd my-release alarm list
+		milvusctl etcd my-release alarm disarm
+		# Save a snapshot of the etcd of milvus my-release to backup.db
+		milvusctl etcd my-release snapshot save backup.db`))
+)
+
+type MilvusEtcdOptions struct {
+	Namespace    string
+	InstanceName string
+	// Action is one of Actions
+	Action       string
+	SnapshotFile string
+	// Revision is the revision compact compacts up to, the current revision when 0
+	Revision int64
+	Physical bool
+	// CommandTimeout is the --command-timeout of etcdctl, defrag may take long on a large database
+	CommandTimeout time.Duration
+	// HealthTimeout bounds the wait for the members to be healthy after a member is defragmented
+	HealthTimeout time.Duration
+	ExecOptions   *kubectlexec.ExecOptions
+	genericclioptions.IOStreams
+}
+
+func NewMilvusEtcdCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := &MilvusEtcdOptions{
+		CommandTimeout: time.Minute,
+		HealthTimeout:  2 * time.Minute,
+		ExecOptions: &kubectlexec.ExecOptions{
+			StreamOptions: kubectlexec.StreamOptions{
+				IOStreams: streams,
+				Quiet:     true,
+			},
+			Executor: &kubectlexec.DefaultRemoteExecutor{},
+		},
+		IOStreams: streams,
+	}
+	cmd := &cobra.Command{
+		Use:                   "etcd NAME (status | endpoint-health | defrag | compact | alarm list | alarm disarm | snapshot save [FILE])",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Run etcdctl maintenance commands against the etcd of milvus instance"),
+		Long:                  etcdLong,
+		Example:               etcdExample,
+		Args:                  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	cmdutil.AddPodRunningTimeoutFlag(cmd, defaultPodExecTimeout)
+	cmdutil.AddContainerVarFlags(cmd, &o.ExecOptions.ContainerName, o.ExecOptions.ContainerName)
+	cmd.Flags().DurationVar(&o.CommandTimeout, "command-timeout", o.CommandTimeout, "The timeout of every etcdctl command")
+	cmd.Flags().DurationVar(&o.HealthTimeout, "health-timeout", o.HealthTimeout, "How long defrag waits for the members to be healthy after defragmenting a member")
+	cmd.Flags().Int64Var(&o.Revision, "revision", o.Revision, "The revision compact compacts the history up to, the current revision by default")
+	cmd.Flags().BoolVar(&o.Physical, "physical", o.Physical, "If true, compact waits for the compacted history to be removed from the database")
+	return cmd
+}
+
+func (o *MilvusEtcdOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	if err := o.ExecOptions.Complete(f, cmd, nil, -1); err != nil {
+		return err
+	}
+
+	o.InstanceName = args[0]
+	o.Action = args[1]
+	rest := args[2:]
+	if (o.Action == "alarm" || o.Action == "snapshot") && len(rest) != 0 {
+		o.Action += " " + rest[0]
+		rest = rest[1:]
+	}
+	if o.Action == ActionSnapshotSave {
+		if len(rest) != 0 {
+			o.SnapshotFile = rest[0]
+			rest = rest[1:]
+		} else {
+			o.SnapshotFile = fmt.Sprintf("%s-etcd-snapshot-%s.db", o.InstanceName, time.Now().Format("20060102150405"))
+		}
+	}
+	if len(rest) != 0 {
+		return cmdutil.UsageErrorf(cmd, "unexpected arguments: %s", strings.Join(rest, " "))
+	}
+	return nil
+}
+
+func (o *MilvusEtcdOptions) Validate() error {
+	for _, action := range Actions {
+		if o.Action == action {
+			return nil
+		}
+	}
+	return fmt.Errorf("etcd command error: %s. choose one of them: %s", o.Action, strings.Join(Actions, ", "))
+}
+
+func (o *MilvusEtcdOptions) Run() error {
+	pods, err := o.Pods()
+	if err != nil {
+		return err
+	}
+	switch o.Action {
+	case ActionStatus:
+		return o.runStatus(pods)
+	case ActionEndpointHealth:
+		return o.runEndpointHealth(pods)
+	case ActionDefrag:
+		return o.runDefrag(pods)
+	case ActionCompact:
+		return o.runCompact(pods)
+	case ActionAlarmList:
+		return o.runAlarm(pods, "list")
+	case ActionAlarmDisarm:
+		return o.runAlarm(pods, "disarm")
+	case ActionSnapshotSave:
+		return o.runSnapshotSave(pods)
+	}
+	return nil
+}
+
+// Pods returns the names of the etcd pods of the instance sorted by name
+func (o *MilvusEtcdOptions) Pods() ([]string, error) {
+	selector, err := milvus.DependenceSelector(o.InstanceName, "etcd")
+	if err != nil {
+		return nil, err
+	}
+	podList, err := o.ExecOptions.PodClient.Pods(o.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	pods := []string{}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod.Name)
+		}
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no running etcd pod found for milvus %s in namespace %s, it may use an external etcd", o.InstanceName, o.Namespace)
+	}
+	sort.Strings(pods)
+	return pods, nil
+}
+
+func (o *MilvusEtcdOptions) statuses(pods []string) []MemberStatus {
+	statuses := []MemberStatus{}
+	for _, pod := range pods {
+		statuses = append(statuses, o.memberStatus(pod))
+	}
+	return statuses
+}
+
+func (o *MilvusEtcdOptions) healths(pods []string) []MemberHealth {
+	healths := []MemberHealth{}
+	for _, pod := range pods {
+		healths = append(healths, o.memberHealth(pod))
+	}
+	return healths
+}
+
+func (o *MilvusEtcdOptions) runStatus(pods []string) error {
+	statuses := o.statuses(pods)
+	if err := WriteStatus(o.Out, statuses); err != nil {
+		return err
+	}
+	failed := 0
+	for _, status := range statuses {
+		if status.Err != nil {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("failed to get the status of %d of %d etcd members", failed, len(statuses))
+	}
+	return nil
+}
+
+func (o *MilvusEtcdOptions) runEndpointHealth(pods []string) error {
+	healths := o.healths(pods)
+	if err := WriteHealth(o.Out, healths); err != nil {
+		return err
+	}
+	if unhealthy := unhealthyPods(healths); len(unhealthy) != 0 {
+		return fmt.Errorf("%d of %d etcd members are unhealthy", len(unhealthy), len(healths))
+	}
+	return nil
+}
+
+func unhealthyPods(healths []MemberHealth) []string {
+	pods := []string{}
+	for _, health := range healths {
+		if !health.IsHealthy() {
+			pods = append(pods, health.Pod)
+		}
+	}
+	return pods
+}
+
+// runDefrag defragments the followers one at a time and the leader last, every member must be healthy
+// before a member is defragmented and the next one waits for them to be healthy again
+func (o *MilvusEtcdOptions) runDefrag(pods []string) error {
+	if unhealthy := unhealthyPods(o.healths(pods)); len(unhealthy) != 0 {
+		return fmt.Errorf("etcd members %s are unhealthy, defrag aborted", strings.Join(unhealthy, ", "))
+	}
+	// the leader is only known from the status of every member, none is defragmented without it
+	statuses := o.statuses(pods)
+	for _, member := range statuses {
+		if member.Err != nil {
+			return fmt.Errorf("failed to get the status of etcd member %s, defrag aborted: %v", member.Pod, member.Err)
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return !isLeader(statuses[i]) && isLeader(statuses[j])
+	})
+
+	for _, member := range statuses {
+		fmt.Fprintf(o.Out, "defragmenting etcd member %s (db size %s, in use %s)\n", member.Pod,
+			byteSize(member.Status.Status.DbSize), byteSize(member.Status.Status.DbSizeInUse))
+		if _, err := o.etcdctl(member.Pod, "defrag"); err != nil {
+			return fmt.Errorf("failed to defragment etcd member %s: %v", member.Pod, err)
+		}
+		if err := o.waitHealthy(pods); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(o.Out)
+	return o.runStatus(pods)
+}
+
+func isLeader(member MemberStatus) bool {
+	return member.Err == nil && member.Status.IsLeader()
+}
+
+// waitHealthy waits until every member is healthy or HealthTimeout elapses
+func (o *MilvusEtcdOptions) waitHealthy(pods []string) error {
+	deadline := time.Now().Add(o.HealthTimeout)
+	for {
+		unhealthy := unhealthyPods(o.healths(pods))
+		if len(unhealthy) == 0 {
+			fmt.Fprintln(o.Out, "all etcd members are healthy")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("etcd members %s are still unhealthy after %s, defrag stopped", strings.Join(unhealthy, ", "), o.HealthTimeout)
+		}
+		time.Sleep(healthInterval)
+	}
+}
+
+// healthyMember returns the first member that answers its status, the cluster wide commands run against it
+func (o *MilvusEtcdOptions) healthyMember(pods []string) (MemberStatus, error) {
+	errs := []string{}
+	for _, pod := range pods {
+		status := o.memberStatus(pod)
+		if status.Err == nil {
+			return status, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", pod, status.Err))
+	}
+	return MemberStatus{}, fmt.Errorf("no etcd member answered:\n  %s", strings.Join(errs, "\n  "))
+}
+
+func (o *MilvusEtcdOptions) runCompact(pods []string) error {
+	member, err := o.healthyMember(pods)
+	if err != nil {
+		return err
+	}
+	revision := o.Revision
+	if revision == 0 {
+		revision = member.Status.Status.Header.Revision
+	}
+	args := []string{"compact", strconv.FormatInt(revision, 10)}
+	if o.Physical {
+		args = append(args, "--physical")
+	}
+	if _, err := o.etcdctl(member.Pod, args...); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "etcd of milvus %s compacted up to revision %d\n", o.InstanceName, revision)
+	return nil
+}
+
+func (o *MilvusEtcdOptions) runAlarm(pods []string, command string) error {
+	member, err := o.healthyMember(pods)
+	if err != nil {
+		return err
+	}
+	output, err := o.etcdctl(member.Pod, "alarm", command)
+	if err != nil {
+		return err
+	}
+	// the members are named after their pods as far as their status is known
+	members := map[uint64]string{}
+	for _, status := range o.statuses(pods) {
+		if status.Err == nil {
+			members[status.Status.Status.Header.MemberID] = status.Pod
+		}
+	}
+	alarms := parseAlarms(output)
+	if command == "disarm" && len(alarms) != 0 {
+		fmt.Fprintln(o.Out, "Disarmed alarms:")
+	}
+	return WriteAlarms(o.Out, alarms, members)
+}
+
+// runSnapshotSave saves a snapshot in a healthy member, copies it to SnapshotFile and removes it from the pod
+func (o *MilvusEtcdOptions) runSnapshotSave(pods []string) error {
+	member, err := o.healthyMember(pods)
+	if err != nil {
+		return err
+	}
+	if _, err := o.etcdctl(member.Pod, "snapshot", "save", snapshotPath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := o.exec(member.Pod, []string{"rm", "-f", snapshotPath}, &bytes.Buffer{}); err != nil {
+			fmt.Fprintf(o.ErrOut, "Warning: failed to remove %s from pod %s: %v\n", snapshotPath, member.Pod, err)
+		}
+	}()
+
+	file, err := os.Create(o.SnapshotFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := o.exec(member.Pod, []string{"cat", snapshotPath}, file); err != nil {
+		os.Remove(o.SnapshotFile)
+		return fmt.Errorf("failed to copy the snapshot from pod %s: %v", member.Pod, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	output, err := o.etcdctl(member.Pod, "snapshot", "status", snapshotPath, "-w", "json")
+	status := &SnapshotStatus{}
+	if err == nil {
+		err = json.Unmarshal(output, status)
+	}
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "Warning: failed to get the status of the snapshot: %v\n", err)
+	} else if err := WriteSnapshotStatus(o.Out, status); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "snapshot of the etcd of milvus %s saved to %s\n", o.InstanceName, o.SnapshotFile)
+	return nil
+}
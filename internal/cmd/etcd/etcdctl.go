@@ -0,0 +1,224 @@
+package etcd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ctlexec "github.com/milvus-io/milvusctl/internal/cmd/exec"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// alarmRegexp matches the alarms printed by etcdctl alarm list and alarm disarm
+var alarmRegexp = regexp.MustCompile(`memberID:(\d+)\s+alarm:(\w+)`)
+
+// EndpointStatus is the status of a member printed by etcdctl endpoint status -w json
+type EndpointStatus struct {
+	Endpoint string `json:"Endpoint"`
+	Status   struct {
+		Header struct {
+			MemberID uint64 `json:"member_id"`
+			Revision int64  `json:"revision"`
+		} `json:"header"`
+		Version     string   `json:"version"`
+		DbSize      int64    `json:"dbSize"`
+		DbSizeInUse int64    `json:"dbSizeInUse"`
+		Leader      uint64   `json:"leader"`
+		RaftIndex   uint64   `json:"raftIndex"`
+		RaftTerm    uint64   `json:"raftTerm"`
+		Errors      []string `json:"errors"`
+	} `json:"Status"`
+}
+
+// IsLeader tells if the member is the leader of the cluster
+func (s EndpointStatus) IsLeader() bool {
+	return s.Status.Header.MemberID != 0 && s.Status.Header.MemberID == s.Status.Leader
+}
+
+// EndpointHealth is the health of a member printed by etcdctl endpoint health -w json
+type EndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	Health   bool   `json:"health"`
+	Took     string `json:"took"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SnapshotStatus is the status of a snapshot printed by etcdctl snapshot status -w json
+type SnapshotStatus struct {
+	Hash      uint32 `json:"hash"`
+	Revision  int64  `json:"revision"`
+	TotalKey  int    `json:"totalKey"`
+	TotalSize int64  `json:"totalSize"`
+}
+
+// Alarm is an alarm raised by a member
+type Alarm struct {
+	MemberID uint64
+	Alarm    string
+}
+
+// MemberStatus is the status of the member running in a pod, Err is set when it could not be read
+type MemberStatus struct {
+	Pod    string
+	Status *EndpointStatus
+	Err    error
+}
+
+// MemberHealth is the health of the member running in a pod, Err is set when it could not be read
+type MemberHealth struct {
+	Pod    string
+	Health *EndpointHealth
+	Err    error
+}
+
+// exec runs the command in the etcd container of the pod and writes its stdout to out,
+// the stderr of the command is added to the error
+func (o *MilvusEtcdOptions) exec(pod string, command []string, out io.Writer) error {
+	options := *o.ExecOptions
+	stderr := &bytes.Buffer{}
+	options.Out = out
+	options.ErrOut = stderr
+	if err := ctlexec.RunInPod(options, pod, command); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%v: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
+
+// etcdctl runs etcdctl against the member of the pod and returns its output
+func (o *MilvusEtcdOptions) etcdctl(pod string, args ...string) ([]byte, error) {
+	command := append([]string{"etcdctl", "--command-timeout=" + o.CommandTimeout.String()}, args...)
+	stdout := &bytes.Buffer{}
+	err := o.exec(pod, command, stdout)
+	return stdout.Bytes(), err
+}
+
+// memberStatus returns the status of the member of the pod
+func (o *MilvusEtcdOptions) memberStatus(pod string) MemberStatus {
+	output, err := o.etcdctl(pod, "endpoint", "status", "-w", "json")
+	if err != nil {
+		return MemberStatus{Pod: pod, Err: err}
+	}
+	statuses := []EndpointStatus{}
+	if err := json.Unmarshal(output, &statuses); err != nil {
+		return MemberStatus{Pod: pod, Err: fmt.Errorf("failed to parse the endpoint status: %v", err)}
+	}
+	if len(statuses) == 0 {
+		return MemberStatus{Pod: pod, Err: fmt.Errorf("no endpoint status")}
+	}
+	return MemberStatus{Pod: pod, Status: &statuses[0]}
+}
+
+// memberHealth returns the health of the member of the pod, etcdctl fails but still prints the health of
+// an unhealthy member
+func (o *MilvusEtcdOptions) memberHealth(pod string) MemberHealth {
+	output, err := o.etcdctl(pod, "endpoint", "health", "-w", "json")
+	healths := []EndpointHealth{}
+	if parseErr := json.Unmarshal(output, &healths); parseErr != nil || len(healths) == 0 {
+		if err == nil {
+			err = fmt.Errorf("failed to parse the endpoint health: %v", parseErr)
+		}
+		return MemberHealth{Pod: pod, Err: err}
+	}
+	return MemberHealth{Pod: pod, Health: &healths[0]}
+}
+
+// IsHealthy tells if the member answered and is healthy
+func (h MemberHealth) IsHealthy() bool {
+	return h.Err == nil && h.Health.Health
+}
+
+// parseAlarms parses the alarms printed by etcdctl alarm list or alarm disarm
+func parseAlarms(output []byte) []Alarm {
+	alarms := []Alarm{}
+	for _, match := range alarmRegexp.FindAllSubmatch(output, -1) {
+		memberID, err := strconv.ParseUint(string(match[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		alarms = append(alarms, Alarm{MemberID: memberID, Alarm: string(match[2])})
+	}
+	return alarms
+}
+
+// WriteStatus prints the table of the status of the members
+func WriteStatus(out io.Writer, statuses []MemberStatus) error {
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintln(w, "POD\tMEMBER\tVERSION\tDB SIZE\tIN USE\tLEADER\tRAFT TERM\tRAFT INDEX\tREVISION\tERRORS")
+	for _, member := range statuses {
+		if member.Err != nil {
+			fmt.Fprintf(w, "%s\t<unknown>\t<unknown>\t<unknown>\t<unknown>\t<unknown>\t<unknown>\t<unknown>\t<unknown>\t%v\n", member.Pod, oneLine(member.Err.Error()))
+			continue
+		}
+		s := member.Status
+		fmt.Fprintf(w, "%s\t%x\t%s\t%s\t%s\t%t\t%d\t%d\t%d\t%s\n", member.Pod, s.Status.Header.MemberID, s.Status.Version,
+			byteSize(s.Status.DbSize), byteSize(s.Status.DbSizeInUse), s.IsLeader(), s.Status.RaftTerm, s.Status.RaftIndex,
+			s.Status.Header.Revision, strings.Join(s.Status.Errors, ", "))
+	}
+	return w.Flush()
+}
+
+// WriteHealth prints the table of the health of the members
+func WriteHealth(out io.Writer, healths []MemberHealth) error {
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintln(w, "POD\tHEALTHY\tTOOK\tERROR")
+	for _, member := range healths {
+		if member.Err != nil {
+			fmt.Fprintf(w, "%s\tfalse\t<unknown>\t%s\n", member.Pod, oneLine(member.Err.Error()))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%t\t%s\t%s\n", member.Pod, member.Health.Health, member.Health.Took, member.Health.Error)
+	}
+	return w.Flush()
+}
+
+// WriteAlarms prints the table of the alarms, the members are named after their pods when they are known
+func WriteAlarms(out io.Writer, alarms []Alarm, pods map[uint64]string) error {
+	if len(alarms) == 0 {
+		fmt.Fprintln(out, "No alarms.")
+		return nil
+	}
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintln(w, "MEMBER\tPOD\tALARM")
+	for _, alarm := range alarms {
+		pod := pods[alarm.MemberID]
+		if pod == "" {
+			pod = "<unknown>"
+		}
+		fmt.Fprintf(w, "%x\t%s\t%s\n", alarm.MemberID, pod, alarm.Alarm)
+	}
+	return w.Flush()
+}
+
+// WriteSnapshotStatus prints the table of the status of a snapshot
+func WriteSnapshotStatus(out io.Writer, status *SnapshotStatus) error {
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintln(w, "HASH\tREVISION\tTOTAL KEYS\tTOTAL SIZE")
+	fmt.Fprintf(w, "%x\t%d\t%d\t%s\n", status.Hash, status.Revision, status.TotalKey, byteSize(status.TotalSize))
+	return w.Flush()
+}
+
+// byteSize prints the size with a binary unit, like 2.0 MiB
+func byteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// oneLine keeps the first line of the error of a command, the table would break on the others
+func oneLine(message string) string {
+	return strings.SplitN(message, "\n", 2)[0]
+}
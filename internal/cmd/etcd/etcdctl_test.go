@@ -0,0 +1,182 @@
+package etcd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outputs in the format printed by etcdctl 3.5
+const (
+	statusOutput   = `[{"Endpoint":"127.0.0.1:2379","Status":{"header":{"cluster_id":14841639068965178418,"member_id":10276657743932975437,"revision":4217,"raft_term":3},"version":"3.5.0","dbSize":2146304,"leader":10276657743932975437,"raftIndex":4325,"raftTerm":3,"raftAppliedIndex":4325,"dbSizeInUse":1064960}}]`
+	followerOutput = `[{"Endpoint":"127.0.0.1:2379","Status":{"header":{"cluster_id":14841639068965178418,"member_id":4091512456098371342,"revision":4217,"raft_term":3},"version":"3.5.0","dbSize":2146304,"leader":10276657743932975437,"raftIndex":4325,"raftTerm":3,"raftAppliedIndex":4325,"dbSizeInUse":1064960,"errors":["memberID:4091512456098371342 alarm:NOSPACE "]}}]`
+	healthOutput   = `[{"endpoint":"127.0.0.1:2379","health":true,"took":"1.763ms"}]`
+	unhealthOutput = `[{"endpoint":"127.0.0.1:2379","health":false,"took":"5.001s","error":"context deadline exceeded"}]`
+	snapshotOutput = `{"hash":3700247624,"revision":4217,"totalKey":121,"totalSize":2146304}`
+	alarmOutput    = "memberID:10276657743932975437 alarm:NOSPACE \nmemberID:4091512456098371342 alarm:CORRUPT \n"
+)
+
+func TestEndpointStatus(t *testing.T) {
+	tests := []struct {
+		output   string
+		memberID uint64
+		leader   bool
+		errors   []string
+	}{
+		{output: statusOutput, memberID: 10276657743932975437, leader: true},
+		{output: followerOutput, memberID: 4091512456098371342, errors: []string{"memberID:4091512456098371342 alarm:NOSPACE "}},
+	}
+	for _, tt := range tests {
+		statuses := []EndpointStatus{}
+		if err := json.Unmarshal([]byte(tt.output), &statuses); err != nil {
+			t.Fatalf("failed to parse the endpoint status: %v", err)
+		}
+		if len(statuses) != 1 {
+			t.Fatalf("got %d statuses, want 1", len(statuses))
+		}
+		s := statuses[0]
+		if s.Status.Header.MemberID != tt.memberID || s.IsLeader() != tt.leader {
+			t.Errorf("member %d leader %t, want %d leader %t", s.Status.Header.MemberID, s.IsLeader(), tt.memberID, tt.leader)
+		}
+		if s.Status.Header.Revision != 4217 || s.Status.DbSize != 2146304 || s.Status.DbSizeInUse != 1064960 || s.Status.RaftTerm != 3 {
+			t.Errorf("unexpected status %+v", s.Status)
+		}
+		if !reflect.DeepEqual(s.Status.Errors, tt.errors) {
+			t.Errorf("errors = %v, want %v", s.Status.Errors, tt.errors)
+		}
+	}
+}
+
+func TestEndpointHealth(t *testing.T) {
+	tests := []struct {
+		output string
+		want   EndpointHealth
+	}{
+		{output: healthOutput, want: EndpointHealth{Endpoint: "127.0.0.1:2379", Health: true, Took: "1.763ms"}},
+		{output: unhealthOutput, want: EndpointHealth{Endpoint: "127.0.0.1:2379", Took: "5.001s", Error: "context deadline exceeded"}},
+	}
+	for _, tt := range tests {
+		healths := []EndpointHealth{}
+		if err := json.Unmarshal([]byte(tt.output), &healths); err != nil {
+			t.Fatalf("failed to parse the endpoint health: %v", err)
+		}
+		if len(healths) != 1 || healths[0] != tt.want {
+			t.Errorf("got %+v, want %+v", healths, tt.want)
+		}
+		member := MemberHealth{Pod: "etcd-0", Health: &healths[0]}
+		if member.IsHealthy() != tt.want.Health {
+			t.Errorf("IsHealthy() = %t, want %t", member.IsHealthy(), tt.want.Health)
+		}
+	}
+	if (MemberHealth{Pod: "etcd-0", Err: fmt.Errorf("exec failed")}).IsHealthy() {
+		t.Error("a member without health is healthy")
+	}
+}
+
+func TestSnapshotStatus(t *testing.T) {
+	status := &SnapshotStatus{}
+	if err := json.Unmarshal([]byte(snapshotOutput), status); err != nil {
+		t.Fatalf("failed to parse the snapshot status: %v", err)
+	}
+	want := SnapshotStatus{Hash: 3700247624, Revision: 4217, TotalKey: 121, TotalSize: 2146304}
+	if *status != want {
+		t.Errorf("got %+v, want %+v", *status, want)
+	}
+	out := &bytes.Buffer{}
+	if err := WriteSnapshotStatus(out, status); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "dc8d4c48") || !strings.Contains(out.String(), "2.0 MiB") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+}
+
+func TestParseAlarms(t *testing.T) {
+	tests := []struct {
+		output string
+		want   []Alarm
+	}{
+		{output: "", want: []Alarm{}},
+		{output: alarmOutput, want: []Alarm{{MemberID: 10276657743932975437, Alarm: "NOSPACE"}, {MemberID: 4091512456098371342, Alarm: "CORRUPT"}}},
+		{output: "memberID:99999999999999999999 alarm:NOSPACE \n", want: []Alarm{}},
+	}
+	for _, tt := range tests {
+		if got := parseAlarms([]byte(tt.output)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAlarms(%q) = %+v, want %+v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestWriteAlarms(t *testing.T) {
+	out := &bytes.Buffer{}
+	pods := map[uint64]string{10276657743932975437: "my-release-etcd-0"}
+	if err := WriteAlarms(out, parseAlarms([]byte(alarmOutput)), pods); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); !reflect.DeepEqual(fields, []string{"8e9e05c52164694d", "my-release-etcd-0", "NOSPACE"}) {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); !reflect.DeepEqual(fields, []string{"38c7f8e6abd4570e", "<unknown>", "CORRUPT"}) {
+		t.Errorf("unexpected row %q", lines[2])
+	}
+
+	out.Reset()
+	if err := WriteAlarms(out, []Alarm{}, pods); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "No alarms.\n" {
+		t.Errorf("got %q, want No alarms.", out.String())
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	statuses := []EndpointStatus{}
+	if err := json.Unmarshal([]byte(statusOutput), &statuses); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	members := []MemberStatus{
+		{Pod: "my-release-etcd-0", Status: &statuses[0]},
+		{Pod: "my-release-etcd-1", Err: fmt.Errorf("command terminated with exit code 1\nError: context deadline exceeded")},
+	}
+	if err := WriteStatus(out, members); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+	want := []string{"my-release-etcd-0", "8e9e05c52164694d", "3.5.0", "2.0", "MiB", "1.0", "MiB", "true", "3", "4325", "4217"}
+	if fields := strings.Fields(lines[1]); !reflect.DeepEqual(fields, want) {
+		t.Errorf("row %v, want %v", fields, want)
+	}
+	if !strings.HasSuffix(lines[2], "command terminated with exit code 1") {
+		t.Errorf("the error of a member is not kept on one line: %q", lines[2])
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{2146304, "2.0 MiB"},
+		{8 * 1024 * 1024 * 1024, "8.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := byteSize(tt.size); got != tt.want {
+			t.Errorf("byteSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
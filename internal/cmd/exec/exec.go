@@ -1,13 +1,23 @@
 package exec
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	kubectlexec "k8s.io/kubectl/pkg/cmd/exec"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
+	"k8s.io/kubectl/pkg/util/term"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,6 +40,12 @@ var (
 		milvusctl exec deploy/mydeployment -- date
 		# Get output from running 'date' command from the first pod of the service myservice, using the first container by default
 		milvusctl exec svc/myservice -- date
+		# Open a shell in a querynode pod of milvus my-release, picked interactively when there are several
+		milvusctl exec milvus/my-release --component=querynode -i -t -- bash
+		# Run 'etcdctl endpoint health' in the second etcd pod of milvus my-release
+		milvusctl exec milvus/my-release --dependence=etcd --pod-index=1 -- etcdctl endpoint health
+		# Run 'df -h' in every datanode pod of milvus my-release, the output is grouped by pod
+		milvusctl exec milvus/my-release --component=datanode --all-pods -- df -h
 		`))
 )
 
@@ -37,6 +53,18 @@ const (
 	defaultPodExecTimeout = 60 * time.Second
 )
 
+// MilvusExecOptions selects the pod of a component or a dependence of a milvus instance for kubectl exec
+type MilvusExecOptions struct {
+	InstanceName string
+	Component    string
+	Dependence   string
+	// PodIndex picks a pod of the candidates sorted by name, -1 picks interactively when there are several
+	PodIndex int
+	// AllPods runs the command in every candidate pod
+	AllPods     bool
+	ExecOptions *kubectlexec.ExecOptions
+}
+
 func NewMilvusExecCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
 	options := &kubectlexec.ExecOptions{
 		StreamOptions: kubectlexec.StreamOptions{
@@ -45,8 +73,9 @@ func NewMilvusExecCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *c
 
 		Executor: &kubectlexec.DefaultRemoteExecutor{},
 	}
+	o := &MilvusExecOptions{PodIndex: -1, ExecOptions: options}
 	cmd := &cobra.Command{
-		Use:                   "exec (POD | TYPE/NAME) [-c CONTAINER] [flags] -- COMMAND [args...]",
+		Use:                   "exec (POD | TYPE/NAME | milvus/NAME (--component COMPONENT | --dependence DEPENDENCE)) [-c CONTAINER] [flags] -- COMMAND [args...]",
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("Execute a command in a container"),
 		Long:                  i18n.T("Execute a command in a container."),
@@ -55,7 +84,12 @@ func NewMilvusExecCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *c
 		Run: func(cmd *cobra.Command, args []string) {
 			argsLenAtDash := cmd.ArgsLenAtDash()
 			cmdutil.CheckErr(options.Complete(f, cmd, args, argsLenAtDash))
+			cmdutil.CheckErr(o.Complete(cmd))
 			cmdutil.CheckErr(options.Validate())
+			if o.InstanceName != "" {
+				cmdutil.CheckErr(o.Run())
+				return
+			}
 			cmdutil.CheckErr(options.Run())
 		},
 	}
@@ -66,5 +100,151 @@ func NewMilvusExecCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *c
 	cmd.Flags().BoolVarP(&options.Stdin, "stdin", "i", options.Stdin, "Pass stdin to the container")
 	cmd.Flags().BoolVarP(&options.TTY, "tty", "t", options.TTY, "Stdin is a TTY")
 	cmd.Flags().BoolVarP(&options.Quiet, "quiet", "q", options.Quiet, "Only print output from the remote session")
+	cmd.Flags().StringVar(&o.Component, "component", o.Component, fmt.Sprintf("With milvus/NAME, exec into a pod of the milvus component, one of: %s", strings.Join(milvus.Components, ", ")))
+	cmd.Flags().StringVar(&o.Dependence, "dependence", o.Dependence, fmt.Sprintf("With milvus/NAME, exec into a pod of the in-cluster dependence, one of: %s", strings.Join(milvus.Dependences, ", ")))
+	cmd.Flags().IntVar(&o.PodIndex, "pod-index", o.PodIndex, "With milvus/NAME, exec into the pod at the index of the pods sorted by name instead of picking it interactively")
+	cmd.Flags().BoolVar(&o.AllPods, "all-pods", o.AllPods, "With milvus/NAME, run the non-interactive command in every pod and print the output grouped by pod")
 	return cmd
 }
+
+// Complete reads the milvus/NAME target, the kubectl exec options are completed first
+func (o *MilvusExecOptions) Complete(cmd *cobra.Command) error {
+	resourceName := o.ExecOptions.ResourceName
+	if !strings.HasPrefix(resourceName, "milvus/") {
+		if o.Component != "" || o.Dependence != "" || o.PodIndex >= 0 || o.AllPods {
+			return cmdutil.UsageErrorf(cmd, "--component, --dependence, --pod-index and --all-pods can only be used with milvus/NAME")
+		}
+		return nil
+	}
+	o.InstanceName = strings.TrimPrefix(resourceName, "milvus/")
+	switch {
+	case o.Component == "" && o.Dependence == "":
+		return cmdutil.UsageErrorf(cmd, "--component or --dependence is required with milvus/NAME")
+	case o.Component != "" && o.Dependence != "":
+		return cmdutil.UsageErrorf(cmd, "only one of --component and --dependence can be specified")
+	case o.AllPods && o.PodIndex >= 0:
+		return cmdutil.UsageErrorf(cmd, "--pod-index and --all-pods conflict, only need to specify one of them")
+	case o.AllPods && (o.ExecOptions.Stdin || o.ExecOptions.TTY):
+		return cmdutil.UsageErrorf(cmd, "--all-pods runs a non-interactive command, it cannot be used with -i or -t")
+	}
+	return nil
+}
+
+// selector returns the label selector of the pods of --component or --dependence
+func (o *MilvusExecOptions) selector() (string, error) {
+	if o.Dependence != "" {
+		return milvus.DependenceSelector(o.InstanceName, o.Dependence)
+	}
+	if !milvus.IsComponent(o.Component) {
+		return "", fmt.Errorf("Milvus component error: %s. choose one of them: %s", o.Component, strings.Join(milvus.Components, ", "))
+	}
+	return milvus.ComponentSelector(o.InstanceName, o.Component), nil
+}
+
+// Pods returns the running pods of --component or --dependence sorted by name
+func (o *MilvusExecOptions) Pods() ([]corev1.Pod, error) {
+	selector, err := o.selector()
+	if err != nil {
+		return nil, err
+	}
+	podList, err := o.ExecOptions.PodClient.Pods(o.ExecOptions.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no running pod of %s found for milvus %s in namespace %s", o.target(), o.InstanceName, o.ExecOptions.Namespace)
+	}
+	return pods, nil
+}
+
+func (o *MilvusExecOptions) target() string {
+	if o.Dependence != "" {
+		return o.Dependence
+	}
+	return o.Component
+}
+
+// Run executes the command in the picked pod, or in every pod with --all-pods
+func (o *MilvusExecOptions) Run() error {
+	pods, err := o.Pods()
+	if err != nil {
+		return err
+	}
+	if o.AllPods {
+		return o.runAll(pods)
+	}
+	pod, err := o.pick(pods)
+	if err != nil {
+		return err
+	}
+	o.ExecOptions.PodName = pod.Name
+	return o.ExecOptions.Run()
+}
+
+// pick returns the pod at --pod-index, the only candidate, or the pod chosen at the prompt when stdin is a terminal
+func (o *MilvusExecOptions) pick(pods []corev1.Pod) (*corev1.Pod, error) {
+	if o.PodIndex >= 0 {
+		if o.PodIndex >= len(pods) {
+			return nil, fmt.Errorf("--pod-index %d is out of range, %d pods of %s are running", o.PodIndex, len(pods), o.target())
+		}
+		return &pods[o.PodIndex], nil
+	}
+	if len(pods) == 1 {
+		return &pods[0], nil
+	}
+
+	names := []string{}
+	for i, pod := range pods {
+		names = append(names, fmt.Sprintf("  [%d] %s", i, pod.Name))
+	}
+	if !term.IsTerminal(o.ExecOptions.In) {
+		return nil, fmt.Errorf("%d pods of %s are running, choose one with --pod-index or run the command in all of them with --all-pods:\n%s",
+			len(pods), o.target(), strings.Join(names, "\n"))
+	}
+	fmt.Fprintf(o.ExecOptions.ErrOut, "%d pods of %s are running:\n%s\nexec into pod [0-%d]: ", len(pods), o.target(), strings.Join(names, "\n"), len(pods)-1)
+	answer, _ := bufio.NewReader(o.ExecOptions.In).ReadString('\n')
+	index, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || index < 0 || index >= len(pods) {
+		return nil, fmt.Errorf("invalid pod index: %q", strings.TrimSpace(answer))
+	}
+	return &pods[index], nil
+}
+
+// runAll executes the command in every pod one after the other, the output of a pod follows a header with its name
+func (o *MilvusExecOptions) runAll(pods []corev1.Pod) error {
+	failed := 0
+	for i, pod := range pods {
+		if i > 0 {
+			fmt.Fprintln(o.ExecOptions.Out)
+		}
+		fmt.Fprintf(o.ExecOptions.Out, "==> %s <==\n", pod.Name)
+		if err := RunInPod(*o.ExecOptions, pod.Name, o.ExecOptions.Command); err != nil {
+			fmt.Fprintf(o.ExecOptions.ErrOut, "error: %s: %v\n", pod.Name, err)
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("the command failed in %d of %d pods", failed, len(pods))
+	}
+	return nil
+}
+
+// RunInPod executes the command in the pod without stdin and terminal, with the clients and streams of
+// the completed options
+func RunInPod(options kubectlexec.ExecOptions, podName string, command []string) error {
+	options.PodName = podName
+	options.Command = command
+	options.Stdin = false
+	options.TTY = false
+	options.Pod = nil
+	return options.Run()
+}
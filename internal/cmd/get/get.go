@@ -24,6 +24,14 @@ var (
 		of the --template flag, you can filter the attributes of the fetched resources.`))
 
 	getExample = templates.Examples(i18n.T(`
+		# List the milvus instances with their mode, version, status, endpoint, replicas and dependencies,
+		# the components which are not fully ready are shown next to the replicas
+		milvusctl get milvus
+		# List the milvus instances of all namespaces with the replicas of every component and the image
+		milvusctl get milvus -A -o wide
+		# Watch the status of the milvus instance my-release
+		milvusctl get milvus my-release --watch
+
 		# List all pods in ps output format
 		kubectl get pods
 		# List all pods in ps output format with more information (such as node name)
@@ -75,6 +83,10 @@ func NewMilvusGetCmd(parent string, f cmdutil.Factory, streams genericclioptions
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(o.Complete(f, cmd, args))
 			cmdutil.CheckErr(o.Validate(cmd))
+			if names, ok := milvusNames(args); ok {
+				cmdutil.CheckErr(runGetMilvus(f, o, names))
+				return
+			}
 			cmdutil.CheckErr(o.Run(f, cmd, args))
 		},
 		SuggestFor: []string{"list", "ps"},
@@ -0,0 +1,311 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/dynamic"
+	kubectlget "k8s.io/kubectl/pkg/cmd/get"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+var milvusResource = v1beta1.GroupVersion.WithResource("milvuses")
+
+// milvusNames reports if the args only ask for milvus instances, as milvus [NAME...]
+// or milvus/NAME..., and returns the names of the instances
+func milvusNames(args []string) ([]string, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	if !strings.Contains(args[0], "/") {
+		return args[1:], isMilvusResource(args[0])
+	}
+	names := []string{}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "/", 2)
+		if !isMilvusResource(parts[0]) {
+			return nil, false
+		}
+		names = append(names, parts[1])
+	}
+	return names, true
+}
+
+func isMilvusResource(resource string) bool {
+	switch strings.ToLower(resource) {
+	case "milvus", "milvuses", "mi", "milvus.milvus.io", "milvuses.milvus.io", "milvuses.v1beta1.milvus.io":
+		return true
+	}
+	return false
+}
+
+// runGetMilvus lists the milvus instances with the columns of milvusctl instead of the printer
+// columns of the CRD, the other output formats print the objects themselves
+func runGetMilvus(f cmdutil.Factory, o *kubectlget.GetOptions, names []string) error {
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+	namespace := o.Namespace
+	if o.AllNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+	client := dynamicClient.Resource(milvusResource).Namespace(namespace)
+	ctx := context.TODO()
+
+	milvuses, resourceVersion, err := listMilvuses(ctx, client, names, o.LabelSelector, o.IgnoreNotFound)
+	if err != nil {
+		return err
+	}
+
+	outputFormat := ""
+	if o.PrintFlags.OutputFormat != nil {
+		outputFormat = *o.PrintFlags.OutputFormat
+	}
+	noHeaders := o.PrintFlags.NoHeaders != nil && *o.PrintFlags.NoHeaders
+	var print func(milvuses []v1beta1.Milvus, headers bool) error
+	switch outputFormat {
+	case "", "wide":
+		table := &milvusTable{wide: outputFormat == "wide", withNamespace: o.AllNamespaces}
+		print = func(milvuses []v1beta1.Milvus, headers bool) error {
+			w := printers.GetNewTabWriter(o.Out)
+			table.print(w, milvuses, headers && !noHeaders)
+			return w.Flush()
+		}
+	default:
+		printer, err := o.PrintFlags.ToPrinter()
+		if err != nil {
+			return err
+		}
+		print = func(milvuses []v1beta1.Milvus, _ bool) error {
+			for i := range milvuses {
+				// the printers need the type meta which the converted objects may miss
+				milvuses[i].SetGroupVersionKind(v1beta1.GroupVersion.WithKind("Milvus"))
+			}
+			if len(milvuses) == 1 && len(names) == 1 {
+				return printer.PrintObj(&milvuses[0], o.Out)
+			}
+			list := &v1beta1.MilvusList{Items: milvuses}
+			list.SetGroupVersionKind(v1beta1.GroupVersion.WithKind("MilvusList"))
+			return printer.PrintObj(list, o.Out)
+		}
+	}
+
+	if !o.WatchOnly {
+		if len(milvuses) == 0 && !o.Watch {
+			if o.AllNamespaces {
+				fmt.Fprintln(o.ErrOut, "No resources found")
+			} else {
+				fmt.Fprintf(o.ErrOut, "No resources found in %s namespace.\n", o.Namespace)
+			}
+			return nil
+		}
+		if len(milvuses) != 0 {
+			if err := print(milvuses, true); err != nil {
+				return err
+			}
+		}
+	}
+	if !o.Watch && !o.WatchOnly {
+		return nil
+	}
+
+	options := metav1.ListOptions{LabelSelector: o.LabelSelector, ResourceVersion: resourceVersion}
+	if len(names) == 1 {
+		options.FieldSelector = "metadata.name=" + names[0]
+	}
+	watcher, err := client.Watch(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+	headers := o.WatchOnly || len(milvuses) == 0
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error {
+			return errors.FromObject(event.Object)
+		}
+		m, err := toMilvus(event.Object)
+		if err != nil {
+			return err
+		}
+		if len(names) > 1 && !contains(names, m.Name) {
+			continue
+		}
+		if err := print([]v1beta1.Milvus{*m}, headers); err != nil {
+			return err
+		}
+		headers = false
+	}
+	return nil
+}
+
+// listMilvuses gets the named instances or lists the ones matching the selector,
+// it returns the resource version to start watching from
+func listMilvuses(ctx context.Context, client dynamic.ResourceInterface, names []string, selector string, ignoreNotFound bool) ([]v1beta1.Milvus, string, error) {
+	milvuses := []v1beta1.Milvus{}
+	if len(names) == 0 {
+		list, err := client.List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, "", err
+		}
+		for i := range list.Items {
+			m, err := toMilvus(&list.Items[i])
+			if err != nil {
+				return nil, "", err
+			}
+			milvuses = append(milvuses, *m)
+		}
+		return milvuses, list.GetResourceVersion(), nil
+	}
+
+	resourceVersion := ""
+	for _, name := range names {
+		object, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) && ignoreNotFound {
+				continue
+			}
+			return nil, "", err
+		}
+		m, err := toMilvus(object)
+		if err != nil {
+			return nil, "", err
+		}
+		milvuses = append(milvuses, *m)
+		resourceVersion = object.GetResourceVersion()
+	}
+	return milvuses, resourceVersion, nil
+}
+
+func toMilvus(object runtime.Object) (*v1beta1.Milvus, error) {
+	u, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", object)
+	}
+	m := &v1beta1.Milvus{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type milvusTable struct {
+	wide          bool
+	withNamespace bool
+}
+
+func (t *milvusTable) print(w io.Writer, milvuses []v1beta1.Milvus, headers bool) {
+	if headers {
+		columns := []string{"NAME", "MODE", "VERSION", "STATUS", "ENDPOINT", "REPLICAS", "DEPENDENCIES", "AGE"}
+		if t.wide {
+			columns = append(columns, "COMPONENTS", "IMAGE")
+		}
+		if t.withNamespace {
+			columns = append([]string{"NAMESPACE"}, columns...)
+		}
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+	}
+	for i := range milvuses {
+		fmt.Fprintln(w, strings.Join(t.row(&milvuses[i]), "\t"))
+	}
+}
+
+func (t *milvusTable) row(m *v1beta1.Milvus) []string {
+	mode := string(m.Spec.Mode)
+	if mode == "" {
+		mode = string(v1beta1.MilvusModeStandalone)
+	}
+	status := string(m.Status.Status)
+	if m.DeletionTimestamp != nil {
+		status = string(v1beta1.StatusDeleting)
+	}
+	replicas := milvus.Replicas(m)
+	row := []string{
+		m.Name,
+		mode,
+		orNone(milvus.ImageVersion(m.Spec.Com.Image)),
+		orNone(status),
+		orNone(m.Status.Endpoint),
+		replicaSummary(replicas),
+		dependencySummary(milvus.Dependencies(&m.Spec)),
+		translateTimestampSince(m.CreationTimestamp),
+	}
+	if t.wide {
+		components := []string{}
+		for _, component := range replicas {
+			components = append(components, fmt.Sprintf("%s:%d/%d", component.Component, component.Ready, component.Desired))
+		}
+		row = append(row, orNone(strings.Join(components, ",")), orNone(m.Spec.Com.Image))
+	}
+	if t.withNamespace {
+		row = append([]string{m.Namespace}, row...)
+	}
+	return row
+}
+
+// replicaSummary is the ready/desired replicas of the instance followed by the components
+// which are not fully ready, like 8/9 (querynode:0/1), -o wide lists every component
+func replicaSummary(replicas []milvus.ComponentReplicas) string {
+	ready, desired := 0, 0
+	lagging := []string{}
+	for _, component := range replicas {
+		ready += component.Ready
+		desired += component.Desired
+		if component.Ready < component.Desired {
+			lagging = append(lagging, fmt.Sprintf("%s:%d/%d", component.Component, component.Ready, component.Desired))
+		}
+	}
+	summary := fmt.Sprintf("%d/%d", ready, desired)
+	if len(lagging) != 0 {
+		summary += " (" + strings.Join(lagging, ",") + ")"
+	}
+	return summary
+}
+
+// dependencySummary lists the dependencies with their kind, like etcd,storage(external),pulsar,
+// in-cluster is the default and is not repeated for each of them
+func dependencySummary(dependencies []milvus.Dependency) string {
+	names := []string{}
+	for _, dependency := range dependencies {
+		name := dependency.Name
+		if dependency.Kind != "in-cluster" {
+			name += "(" + dependency.Kind + ")"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+func translateTimestampSince(timestamp metav1.Time) string {
+	if timestamp.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(metav1.Now().Sub(timestamp.Time))
+}
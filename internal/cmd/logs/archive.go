@@ -0,0 +1,190 @@
+package logs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	FormatZip   = "zip"
+	FormatTarGz = "tar.gz"
+	FormatDir   = "dir"
+)
+
+// Formats are the supported formats of the saved logs and bundles
+var Formats = []string{FormatZip, FormatTarGz, FormatDir}
+
+// ValidateFormat returns an error when the format is not one of Formats
+func ValidateFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("format error: %s. choose one of them: zip, tar.gz, dir", format)
+}
+
+// ArchiveName returns the path of the archive of srcDir in the format, srcDir itself with the dir format
+func ArchiveName(srcDir string, format string) string {
+	switch format {
+	case FormatZip, FormatTarGz:
+		return srcDir + "." + format
+	}
+	return srcDir
+}
+
+// Archive packs srcDir into srcDir.zip or srcDir.tar.gz and removes it, it is left as it is with the dir format.
+// It returns the path of the archive or of the directory
+func Archive(srcDir string, format string) (string, error) {
+	var err error
+	archive := ArchiveName(srcDir, format)
+	switch format {
+	case FormatZip:
+		err = Zip(srcDir, archive)
+	case FormatTarGz:
+		err = TarGz(srcDir, archive)
+	case FormatDir:
+		return srcDir, nil
+	default:
+		return "", ValidateFormat(format)
+	}
+	if err != nil {
+		return "", err
+	}
+	return archive, os.RemoveAll(srcDir)
+}
+
+// Zip packs srcDir into the zip file, the partial file is removed when it fails
+func Zip(srcDir string, zipFileName string) (err error) {
+	if Exists(zipFileName) {
+		return fmt.Errorf("Unable to compress: file %s already exists, remove it first", zipFileName)
+	}
+	// deferred first, it runs once the file is closed
+	defer removeOnError(zipFileName, &err)
+
+	// Create zip file
+	zipFile, err := os.Create(zipFileName)
+	if err != nil {
+		return err
+	}
+	defer zipFile.Close()
+
+	// Open the zip file
+	archive := zip.NewWriter(zipFile)
+	err = walk(srcDir, func(path string, name string, info os.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += `/`
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return copyFile(writer, path)
+	})
+	if err != nil {
+		archive.Close()
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return zipFile.Close()
+}
+
+// TarGz packs srcDir into the tar.gz file, the partial file is removed when it fails
+func TarGz(srcDir string, tarFileName string) (err error) {
+	if Exists(tarFileName) {
+		return fmt.Errorf("Unable to compress: file %s already exists, remove it first", tarFileName)
+	}
+	// deferred first, it runs once the file is closed
+	defer removeOnError(tarFileName, &err)
+
+	tarFile, err := os.Create(tarFileName)
+	if err != nil {
+		return err
+	}
+	defer tarFile.Close()
+
+	gzipWriter := gzip.NewWriter(tarFile)
+	archive := tar.NewWriter(gzipWriter)
+	err = walk(srcDir, func(path string, name string, info os.FileInfo) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += `/`
+		}
+
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return copyFile(archive, path)
+	})
+	if err != nil {
+		archive.Close()
+		gzipWriter.Close()
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return tarFile.Close()
+}
+
+// removeOnError removes the partial archive when packing it failed
+func removeOnError(fileName string, err *error) {
+	if *err != nil {
+		os.Remove(fileName)
+	}
+}
+
+// walk calls fn with the path and the slash separated name relative to srcDir of everything under srcDir
+func walk(srcDir string, fn func(path string, name string, info os.FileInfo) error) error {
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == srcDir {
+			return nil
+		}
+		name, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		return fn(path, filepath.ToSlash(name), info)
+	})
+}
+
+func copyFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
@@ -0,0 +1,105 @@
+package logs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// DefaultParallel is the number of logs saved at the same time
+	DefaultParallel = 5
+	// ManifestFile lists the saved logs and the failures next to them
+	ManifestFile = "manifest.json"
+)
+
+// Manifest lists the log files that are saved and the logs that could not be saved
+type Manifest struct {
+	Instance  string    `json:"instance"`
+	Namespace string    `json:"namespace"`
+	Files     []string  `json:"files"`
+	Failures  []Failure `json:"failures"`
+}
+
+// Failure is a component whose pods could not be listed or a pod whose logs could not be saved
+type Failure struct {
+	Component string `json:"component"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	Error     string `json:"error"`
+}
+
+// logCollector saves the logs of the pods with at most parallel requests at the same time
+type logCollector struct {
+	sem      chan struct{}
+	wg       sync.WaitGroup
+	redactor *Redactor
+
+	mu       sync.Mutex
+	manifest *Manifest
+}
+
+func newLogCollector(parallel int, redactor *Redactor, manifest *Manifest) *logCollector {
+	if parallel <= 0 {
+		parallel = DefaultParallel
+	}
+	return &logCollector{
+		sem:      make(chan struct{}, parallel),
+		redactor: redactor,
+		manifest: manifest,
+	}
+}
+
+// save saves the logs of the request in the background, the failures are recorded in the manifest
+// unless optional is set, in which case the file is dropped
+func (c *logCollector) save(component string, objRef corev1.ObjectReference, fileName string, request rest.ResponseWrapper, optional bool) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.sem <- struct{}{}
+		defer func() { <-c.sem }()
+
+		err := SavePodLogs(fileName, request, c.redactor)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		switch {
+		case err == nil:
+			c.manifest.Files = append(c.manifest.Files, filepath.Base(fileName))
+		case optional:
+			os.Remove(fileName)
+		default:
+			os.Remove(fileName)
+			container := ""
+			if matches := containerFieldPathRegexp.FindStringSubmatch(objRef.FieldPath); len(matches) == 2 {
+				container = matches[1]
+			}
+			c.manifest.Failures = append(c.manifest.Failures, Failure{Component: component, Pod: objRef.Name, Container: container, Error: err.Error()})
+		}
+	}()
+}
+
+// fail records a component whose logs could not be requested
+func (c *logCollector) fail(component string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.manifest.Failures = append(c.manifest.Failures, Failure{Component: component, Error: err.Error()})
+}
+
+// wait blocks until every log is saved and writes the manifest into dir
+func (c *logCollector) wait(dir string) (*Manifest, error) {
+	c.wg.Wait()
+	sort.Strings(c.manifest.Files)
+	sort.SliceStable(c.manifest.Failures, func(i, j int) bool {
+		return c.manifest.Failures[i].Component+c.manifest.Failures[i].Pod < c.manifest.Failures[j].Component+c.manifest.Failures[j].Pod
+	})
+	data, err := json.MarshalIndent(c.manifest, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return c.manifest, os.WriteFile(filepath.Join(dir, ManifestFile), append(data, '\n'), 0644)
+}
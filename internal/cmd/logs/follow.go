@@ -0,0 +1,236 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// prefixColors are picked by the hash of the component and of the pod name
+var prefixColors = []string{"\033[31m", "\033[32m", "\033[33m", "\033[34m", "\033[35m", "\033[36m", "\033[91m", "\033[92m", "\033[93m", "\033[94m", "\033[95m", "\033[96m"}
+
+const colorReset = "\033[0m"
+
+// logTarget is a milvus component or a dependence whose pods are followed
+type logTarget struct {
+	name     string
+	selector string
+}
+
+// component names the component of a pod of the target, the pods of the whole instance are named
+// after their component label and the pulsar pods after their pulsar component
+func (t logTarget) component(pod *corev1.Pod) string {
+	component := t.name
+	if component == "" {
+		component = pod.Labels["app.kubernetes.io/component"]
+	}
+	if t.name == "pulsar" && pod.Labels["component"] != "" {
+		component = "pulsar-" + pod.Labels["component"]
+	}
+	return component
+}
+
+// logPrefix prefixes the lines of a container with component/pod, and with the container when the pod has several
+func logPrefix(component string, pod *corev1.Pod, container string, color bool) string {
+	prefix := component + "/" + pod.Name
+	if len(pod.Spec.Containers) > 1 {
+		prefix += "/" + container
+	}
+	if color {
+		prefix = prefixColors[hash(component+pod.Name)%uint32(len(prefixColors))] + prefix + colorReset
+	}
+	return prefix
+}
+
+// logTargets returns the selected milvus components and in-cluster dependences
+func (o MilvusLogsOptions) logTargets() ([]logTarget, error) {
+	targets := []logTarget{}
+	if o.SaveAll {
+		targets = append(targets, logTarget{selector: milvus.InstanceSelector(o.InstanceName)})
+	} else {
+		for _, component := range o.components() {
+			switch {
+			case component == "all":
+				targets = append(targets, logTarget{selector: milvus.InstanceSelector(o.InstanceName)})
+			case milvus.IsComponent(component):
+				targets = append(targets, logTarget{name: component, selector: milvus.ComponentSelector(o.InstanceName, component)})
+			default:
+				return nil, componentError(component)
+			}
+		}
+	}
+	for _, dependence := range o.dependences() {
+		selector, err := milvus.DependenceSelector(o.InstanceName, dependence)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, logTarget{name: dependence, selector: selector})
+	}
+	return targets, nil
+}
+
+// logStreamer follows the containers of the pods of the targets and writes their lines with a component/pod prefix
+type logStreamer struct {
+	clientset kubernetes.Interface
+	namespace string
+	options   *corev1.PodLogOptions
+	container string
+	color     bool
+	out       io.Writer
+	errOut    io.Writer
+
+	// started is when the streamer started, the pods created later are followed from their first line
+	started time.Time
+
+	mu sync.Mutex
+	// streams are the started container instances, keyed by pod uid, container and restart count
+	streams map[string]bool
+}
+
+// FollowLogs streams the logs of every pod of the selected components and dependences at the same time,
+// the pods that appear later, after a scale-up or a restart, are followed as soon as they run
+func (o MilvusLogsOptions) FollowLogs(f cmdutil.Factory) error {
+	targets, err := o.logTargets()
+	if err != nil {
+		return err
+	}
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	options, err := o.LogsOptions.ToLogOptions()
+	if err != nil {
+		return err
+	}
+	// the container is picked per pod
+	options.Container = ""
+	// like kubectl following a selector, the running pods start from their last lines unless --tail is set
+	if o.LogsOptions.Tail == -1 && !o.LogsOptions.TailSpecified {
+		options.TailLines = &selectorTail
+	}
+
+	s := &logStreamer{
+		clientset: clientset,
+		namespace: o.Namespace,
+		options:   options,
+		container: o.LogsOptions.Container,
+		color:     term.IsTerminal(o.LogsOptions.Out),
+		out:       o.LogsOptions.Out,
+		errOut:    o.LogsOptions.ErrOut,
+		started:   time.Now(),
+		streams:   map[string]bool{},
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	for _, target := range targets {
+		target := target
+		factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithNamespace(o.Namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = target.selector
+			}))
+		pods := factory.Core().V1().Pods().Informer()
+		pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				s.follow(ctx, target, obj.(*corev1.Pod))
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				s.follow(ctx, target, obj.(*corev1.Pod))
+			},
+		})
+		factory.Start(ctx.Done())
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// follow starts a stream for every running container of the pod that is not followed yet
+func (s *logStreamer) follow(ctx context.Context, target logTarget, pod *corev1.Pod) {
+	if pod.DeletionTimestamp != nil {
+		return
+	}
+	component := target.component(pod)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running == nil || (s.container != "" && status.Name != s.container) {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%d", pod.UID, status.Name, status.RestartCount)
+		s.mu.Lock()
+		if s.streams[key] {
+			s.mu.Unlock()
+			continue
+		}
+		s.streams[key] = true
+		s.mu.Unlock()
+
+		prefix := logPrefix(component, pod, status.Name, s.color)
+		options := s.options.DeepCopy()
+		options.Container = status.Name
+		switch {
+		case status.RestartCount > 0 && status.State.Running.StartedAt.After(s.started):
+			// the container restarted while following, only the lines of the new instance are wanted
+			options.TailLines = nil
+			options.SinceSeconds = nil
+			options.SinceTime = status.State.Running.StartedAt.DeepCopy()
+		case pod.CreationTimestamp.After(s.started):
+			options.TailLines = nil
+			options.SinceSeconds = nil
+			options.SinceTime = nil
+		}
+
+		go func(name string) {
+			if err := s.stream(ctx, name, options, prefix); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(s.errOut, "error following %s: %v\n", prefix, err)
+			}
+		}(pod.Name)
+	}
+}
+
+func (s *logStreamer) stream(ctx context.Context, podName string, options *corev1.PodLogOptions, prefix string) error {
+	readCloser, err := s.clientset.CoreV1().Pods(s.namespace).GetLogs(podName, options).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer readCloser.Close()
+
+	r := bufio.NewReader(readCloser)
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) != 0 {
+			s.mu.Lock()
+			_, werr := fmt.Fprintf(s.out, "%s %s\n", prefix, strings.TrimSuffix(line, "\n"))
+			s.mu.Unlock()
+			if werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+func hash(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -1,18 +1,20 @@
 package logs
 
 import (
-	"archive/zip"
 	"bufio"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/milvus-io/milvusctl/pkg/milvus"
 	"github.com/spf13/cobra"
 
 	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
 	kubectllogs "k8s.io/kubectl/pkg/cmd/logs"
@@ -33,13 +35,26 @@ var (
 	logsLong = templates.LongDesc(i18n.T(`
 		Print the logs for a container in a pod or specified resource 
 		If the pod has only one container, the container name is optional.
-		Or export the logs for Milvus and its' dependences.`))
+		Or export the logs for Milvus and its' dependences.
+		With --grep, --level or --output the log entries of Milvus are printed in timestamp order instead.`))
 
 	logsExample = templates.Examples(i18n.T(`
 		# Save all Milvus component and its' dependences logs
 		milvusctl logs milvus/milvus-release --all
 		# Save the specify milvus componenet and dependences logs
 		milvusctl logs milvus/milvus-release --component=datanode --etcd
+		# Save the last hour of the milvus logs, with the logs of the crashed containers in <pod>.previous.log
+		milvusctl logs milvus/milvus-release --since=1h --previous
+		# Save all the logs as milvus-release.tar.gz, 10 pods at a time
+		milvusctl logs milvus/milvus-release --all --format=tar.gz --parallel=10
+		# Mask the patterns of redact.txt too, what is masked is listed in redaction-report.json
+		milvusctl logs milvus/milvus-release --redact-patterns=redact.txt
+		# Print the warnings and errors of proxy, querycoord and querynode mentioning the collection 434, merged by time
+		milvusctl logs milvus/milvus-release --component=proxy,querycoord,querynode --grep=434 --level=warn
+		# Print the parsed errors of the last hour as json, with their key/value fields
+		milvusctl logs milvus/milvus-release --level=error --since=1h -o json
+		# Follow the logs of every querynode and proxy pod and of etcd, prefixed by component/pod
+		milvusctl logs milvus/milvus-release -f --component=querynode,proxy --etcd
 		# Return snapshot logs from pod nginx with only one container
 		milvusctl logs nginx
 		# Return snapshot logs from pod nginx with multi containers
@@ -63,8 +78,10 @@ var (
 		# Return snapshot logs from container nginx-1 of a deployment named nginx
 		milvusctl logs deployment/nginx -c nginx-1`))
 
-	selectorTail    int64 = 10
-	logsUsageErrStr       = fmt.Sprintf("expected '%s'.\nPOD or TYPE/NAME is a required argument for the logs command", logsUsageStr)
+	selectorTail int64 = 10
+	// containerFieldPathRegexp extracts the container name from the field path of a log request
+	containerFieldPathRegexp = regexp.MustCompile(`spec\.(?:initContainers|containers|ephemeralContainers){(.+)}`)
+	logsUsageErrStr          = fmt.Sprintf("expected '%s'.\nPOD or TYPE/NAME is a required argument for the logs command", logsUsageStr)
 )
 
 const (
@@ -81,7 +98,22 @@ type MilvusLogsOptions struct {
 	Minio            bool
 	Pulsar           bool
 	Kafka            bool
-	LogsOptions      *kubectllogs.LogsOptions
+	// Format is the format of the saved logs, one of zip, tar.gz or dir
+	Format string
+	// Parallel bounds the logs saved at the same time
+	Parallel int
+	// Redact masks the credentials in the saved logs, with the patterns of the RedactPatterns file too
+	Redact         bool
+	RedactPatterns string
+	// Redactor is shared by the logs and the other files of a support bundle, it is built by SaveLogs when nil
+	Redactor *Redactor
+	// Grep and Level filter the printed log entries, Output prints them as json
+	Grep        string
+	Level       string
+	Output      string
+	LogsOptions *kubectllogs.LogsOptions
+
+	collector *logCollector
 }
 
 func NewMilvusLogsOptions(streams genericclioptions.IOStreams, allContainers bool) *MilvusLogsOptions {
@@ -89,6 +121,9 @@ func NewMilvusLogsOptions(streams genericclioptions.IOStreams, allContainers boo
 		FilePath:         "./milvus-logs",
 		Namespace:        "default",
 		MilvusComponenet: "all",
+		Format:           FormatZip,
+		Parallel:         DefaultParallel,
+		Redact:           true,
 		LogsOptions:      kubectllogs.NewLogsOptions(streams, allContainers),
 	}
 }
@@ -109,6 +144,14 @@ func NewMilvusLogsCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *c
 				// fmt.Println("export milvus logs")
 				cmdutil.CheckErr(o.Complete(f, cmd, args))
 				cmdutil.CheckErr(o.Validate())
+				if o.LogsOptions.Follow {
+					cmdutil.CheckErr(o.FollowLogs(f))
+					return
+				}
+				if o.searching() {
+					cmdutil.CheckErr(o.SearchLogs(f))
+					return
+				}
 				cmdutil.CheckErr(o.RunLogs(f, cmd, args))
 			} else {
 				cmdutil.CheckErr(o.LogsOptions.Complete(f, cmd, args))
@@ -122,15 +165,22 @@ func NewMilvusLogsCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *c
 	logsCmd.Flags().StringVarP(&o.FilePath, "dir", "d", o.FilePath, "Specify the path where the logs saved")
 	// logsCmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "use type parameter to choose install namespace")
 	logsCmd.Flags().BoolVar(&o.SaveAll, "all", o.SaveAll, "Specify if saved all the logs of Milvus and dependences")
-	logsCmd.Flags().StringVar(&o.MilvusComponenet, "component", o.MilvusComponenet, "choose which milvus component's logs to export.")
+	logsCmd.Flags().StringVar(&o.MilvusComponenet, "component", o.MilvusComponenet, "choose which milvus components' logs to export or follow, separated by commas.")
 	logsCmd.Flags().BoolVar(&o.Etcd, "etcd", o.Etcd, "Specify if saved the logs of etcd")
 	logsCmd.Flags().BoolVar(&o.Minio, "minio", o.Minio, "Specify if saved the logs of minio")
 	logsCmd.Flags().BoolVar(&o.Pulsar, "pulsar", o.Pulsar, "Specify if saved the logs of pulsar")
 	logsCmd.Flags().BoolVar(&o.Kafka, "kafka", o.Kafka, "Specify if saved the logs of kafka")
+	logsCmd.Flags().StringVar(&o.Format, "format", o.Format, "The format of the saved logs, one of: zip, tar.gz, dir")
+	logsCmd.Flags().IntVar(&o.Parallel, "parallel", o.Parallel, "The number of pods whose logs are saved at the same time")
+	logsCmd.Flags().BoolVar(&o.Redact, "redact", o.Redact, "If true, mask the credentials and the values of the secrets referenced by the milvus spec in the saved logs")
+	logsCmd.Flags().StringVar(&o.Grep, "grep", o.Grep, "Print the log entries of the milvus pods matching the regular expression instead of saving the logs")
+	logsCmd.Flags().StringVar(&o.Level, "level", o.Level, "Print the log entries of the milvus pods at or above the level instead of saving the logs, one of: debug, info, warn, error, dpanic, panic, fatal")
+	logsCmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "Print the log entries of the milvus pods in the format instead of saving the logs, only json is supported")
+	logsCmd.Flags().StringVar(&o.RedactPatterns, "redact-patterns", o.RedactPatterns, "A file of regular expressions to mask in the saved logs, one per line, only the (?P<secret>...) group is masked when there is one")
 	return logsCmd
 }
 
-func (o MilvusLogsOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+func (o *MilvusLogsOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
 	var err error
 
 	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
@@ -139,7 +189,15 @@ func (o MilvusLogsOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args
 	}
 
 	o.InstanceName = args[0][7:]
+	o.LogsOptions.TailSpecified = cmd.Flag("tail").Changed
+	if o.LogsOptions.Follow || o.searching() {
+		// the followed and searched logs are printed, nothing is saved
+		return nil
+	}
 	dir := filepath.Join(o.FilePath, o.InstanceName)
+	if archive := ArchiveName(dir, o.Format); archive != dir && Exists(archive) {
+		return fmt.Errorf("File %s already exists, remove it or specify a new path", archive)
+	}
 	if !Exists(o.FilePath) {
 		if err = os.MkdirAll(dir, os.ModePerm); err != nil {
 			return err
@@ -167,99 +225,129 @@ func (o MilvusLogsOptions) Validate() error {
 	if o.SaveAll && o.MilvusComponenet != "all" {
 		return fmt.Errorf("Parameter ‘component’ and ‘all’ conflict, only need to specify one of them")
 	}
-	return nil
+	if o.Parallel <= 0 {
+		return fmt.Errorf("--parallel must be greater than 0")
+	}
+	if o.searching() {
+		if o.LogsOptions.Follow {
+			return fmt.Errorf("--grep, --level and --output cannot be used with --follow")
+		}
+		if o.Output != "" && o.Output != "json" {
+			return fmt.Errorf("output format error: %s. only json is supported", o.Output)
+		}
+		if _, err := o.logFilter(); err != nil {
+			return err
+		}
+	}
+	return ValidateFormat(o.Format)
 }
 
+// RunLogs saves and archives the logs, the failures are reported once the archive is saved
 func (o MilvusLogsOptions) RunLogs(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
 	o.InstanceName = args[0][7:]
-	if o.SaveAll {
-		if err := o.SaveMilvusComLogs(f, cmd); err != nil {
-			fmt.Println(err)
-		}
-		if err := o.SaveEtcdComLogs(f, cmd); err != nil {
-			fmt.Println(err)
-		}
-		if err := o.SavePulsarComLogs(f, cmd); err != nil {
-			fmt.Println(err)
-		}
-		if err := o.SaveKafkaComLogs(f, cmd); err != nil {
-			fmt.Println(err)
-		}
-		if err := o.SaveMinioComLogs(f, cmd); err != nil {
-			fmt.Println(err)
-		}
-	} else {
-		if err := o.SaveMilvusComLogs(f, cmd); err != nil {
-			fmt.Println(err)
-		}
-		if o.Etcd {
-			if err := o.SaveEtcdComLogs(f, cmd); err != nil {
-				fmt.Println(err)
-			}
-		}
-		if o.Pulsar {
-			if err := o.SavePulsarComLogs(f, cmd); err != nil {
-				fmt.Println(err)
-			}
+	saveErr := o.SaveLogs(f, cmd)
+	archive, err := Archive(filepath.Join(o.FilePath, o.InstanceName), o.Format)
+	if err != nil {
+		return utilerrors.NewAggregate([]error{saveErr, err})
+	}
+	fmt.Printf("Milvus logs saved to %s \n", archive)
+	return saveErr
+}
+
+// SaveLogs saves the logs of the selected milvus components and dependences under FilePath/InstanceName,
+// together with a manifest of the saved files and of the failures, which do not stop the other logs
+func (o MilvusLogsOptions) SaveLogs(f cmdutil.Factory, cmd *cobra.Command) (err error) {
+	dir := filepath.Join(o.FilePath, o.InstanceName)
+	if o.Redactor == nil && o.Redact {
+		redactor, err := o.NewRedactor(f)
+		if err != nil {
+			return err
 		}
-		if o.Kafka {
-			if err := o.SaveKafkaComLogs(f, cmd); err != nil {
-				fmt.Println(err)
+		o.Redactor = redactor
+		defer func() {
+			// without the report the reviewer can not tell what was masked
+			if reportErr := redactor.WriteReport(filepath.Join(dir, RedactionReportFile)); reportErr != nil {
+				err = utilerrors.Flatten(utilerrors.NewAggregate([]error{err, reportErr}))
 			}
+		}()
+	}
+	o.collector = newLogCollector(o.Parallel, o.Redactor, &Manifest{Instance: o.InstanceName, Namespace: o.Namespace, Files: []string{}, Failures: []Failure{}})
+	allErrs := []error{}
+	if err := o.SaveMilvusComLogs(f, cmd); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	for _, dependence := range o.dependences() {
+		var err error
+		switch dependence {
+		case "etcd":
+			err = o.SaveEtcdComLogs(f, cmd)
+		case "pulsar":
+			err = o.SavePulsarComLogs(f, cmd)
+		case "kafka":
+			err = o.SaveKafkaComLogs(f, cmd)
+		case "minio":
+			err = o.SaveMinioComLogs(f, cmd)
 		}
-		if o.Minio {
-			if err := o.SaveMinioComLogs(f, cmd); err != nil {
-				fmt.Println(err)
-			}
+		if err != nil {
+			allErrs = append(allErrs, err)
 		}
 	}
-	zipDir := filepath.Join(o.FilePath, o.InstanceName+".gz")
-	if err := Zip(filepath.Join(o.FilePath, o.InstanceName), zipDir); err != nil {
-		return err
+
+	manifest, err := o.collector.wait(dir)
+	if err != nil {
+		allErrs = append(allErrs, err)
+	} else if len(manifest.Failures) != 0 {
+		allErrs = append(allErrs, fmt.Errorf("failed to save %d logs, the failures are listed in %s", len(manifest.Failures), ManifestFile))
 	}
+	return utilerrors.NewAggregate(allErrs)
+}
 
+func (o MilvusLogsOptions) SaveMilvusComLogs(f cmdutil.Factory, cmd *cobra.Command) error {
+	for _, component := range o.components() {
+		var err error
+		switch {
+		case component == "all":
+			err = o.writeLogs(f, cmd, milvus.InstanceSelector(o.InstanceName), "milvus")
+		case milvus.IsComponent(component):
+			err = o.writeLogs(f, cmd, milvus.ComponentSelector(o.InstanceName, component), "milvus "+component)
+		default:
+			return componentError(component)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (o MilvusLogsOptions) SaveMilvusComLogs(f cmdutil.Factory, cmd *cobra.Command) error {
-	labels := "app.kubernetes.io/instance=" + o.InstanceName + ", app.kubernetes.io/name=milvus"
-	// fmt.Println("milvus labels: ", labels)
-	var err error
-	switch o.MilvusComponenet {
-	case "proxy":
-		labels = labels + ", app.kubernetes.io/component=proxy"
-		err = o.writeLogs(f, cmd, labels, "milvus proxy")
-	case "rootcoord":
-		labels = labels + ", app.kubernetes.io/component=rootcoord"
-		err = o.writeLogs(f, cmd, labels, "milvus rootcoord")
-	case "mixcoord":
-		labels = labels + ", app.kubernetes.io/component=mixcoord"
-		err = o.writeLogs(f, cmd, labels, "milvus mixcoord")
-	case "indexcoord":
-		labels = labels + ", app.kubernetes.io/component=indexcoord"
-		err = o.writeLogs(f, cmd, labels, "milvus indexcoord")
-	case "querycoord":
-		labels = labels + ", app.kubernetes.io/component=querycoord"
-		err = o.writeLogs(f, cmd, labels, "milvus querycoord")
-	case "datacoord":
-		labels = labels + ", app.kubernetes.io/component=datacoord"
-		err = o.writeLogs(f, cmd, labels, "milvus datacoord")
-	case "indexnode":
-		labels = labels + ", app.kubernetes.io/component=indexnode"
-		err = o.writeLogs(f, cmd, labels, "milvus indexnode")
-	case "querynode":
-		labels = labels + ", app.kubernetes.io/component=querynode"
-		err = o.writeLogs(f, cmd, labels, "milvus querynode")
-	case "datanode":
-		labels = labels + ", app.kubernetes.io/component=datanode"
-		err = o.writeLogs(f, cmd, labels, "milvus datanode")
-	case "all":
-		err = o.writeLogs(f, cmd, labels, "milvus")
-	default:
-		return fmt.Errorf("Milvus component error: %s. choose one of them: proxy, rootcoord, mixcoord, indexcoord, querycoord, datacoord, indexnode, querynode, datanode, all", o.MilvusComponenet)
+// components returns the milvus components of the comma separated --component
+func (o MilvusLogsOptions) components() []string {
+	components := []string{}
+	for _, component := range strings.Split(o.MilvusComponenet, ",") {
+		if component = strings.TrimSpace(component); component != "" {
+			components = append(components, component)
+		}
 	}
+	return components
+}
 
-	return err
+// dependences returns the dependences whose flag is set, or all of them with --all
+func (o MilvusLogsOptions) dependences() []string {
+	if o.SaveAll {
+		return milvus.Dependences
+	}
+	selected := map[string]bool{"etcd": o.Etcd, "minio": o.Minio, "pulsar": o.Pulsar, "kafka": o.Kafka}
+	dependences := []string{}
+	for _, dependence := range milvus.Dependences {
+		if selected[dependence] {
+			dependences = append(dependences, dependence)
+		}
+	}
+	return dependences
+}
+
+func componentError(component string) error {
+	return fmt.Errorf("Milvus component error: %s. choose some of them: %s, all", component, strings.Join(milvus.Components, ", "))
 }
 
 func (o MilvusLogsOptions) SaveEtcdComLogs(f cmdutil.Factory, cmd *cobra.Command) error {
@@ -285,25 +373,54 @@ func (o MilvusLogsOptions) SaveMinioComLogs(f cmdutil.Factory, cmd *cobra.Comman
 }
 
 func (o MilvusLogsOptions) writeLogs(f cmdutil.Factory, cmd *cobra.Command, labels string, com string) error {
-	requests, err := o.GetObjRequest(f, cmd, labels)
+	options, err := o.LogsOptions.ToLogOptions()
+	if err != nil {
+		return err
+	}
+	options.Previous = false
+	requests, err := o.GetObjRequest(f, cmd, labels, options)
 	if err != nil {
+		o.collector.fail(com, err)
 		return nil
 	}
 	if len(requests) != 0 {
 		fmt.Printf("%v pods founded for the %s component, export the log of them \n", len(requests), com)
 	}
 	for objRef, request := range requests {
-		fileName := filepath.Join(o.FilePath, o.InstanceName, objRef.Name+".log")
-		err := SavePodLogs(fileName, request)
-		if err != nil {
-			return err
-			// fmt.Println(err)
-		}
+		fileName := filepath.Join(o.FilePath, o.InstanceName, o.logFileName(objRef, ".log"))
+		o.collector.save(com, objRef, fileName, request, false)
+	}
+	if !o.LogsOptions.Previous {
+		return nil
+	}
+
+	options.Previous = true
+	requests, err = o.GetObjRequest(f, cmd, labels, options)
+	if err != nil {
+		o.collector.fail(com, err)
+		return nil
+	}
+	for objRef, request := range requests {
+		fileName := filepath.Join(o.FilePath, o.InstanceName, o.logFileName(objRef, ".previous.log"))
+		// the containers that never restarted have no previous logs
+		o.collector.save(com, objRef, fileName, request, true)
 	}
 	return nil
 }
 
-func (o MilvusLogsOptions) GetObjRequest(f cmdutil.Factory, cmd *cobra.Command, labels string) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
+// logFileName names the log file after the pod, and after the container too when the logs of all containers are saved
+func (o MilvusLogsOptions) logFileName(objRef corev1.ObjectReference, suffix string) string {
+	if !o.LogsOptions.AllContainers {
+		return objRef.Name + suffix
+	}
+	container := objRef.FieldPath
+	if matches := containerFieldPathRegexp.FindStringSubmatch(objRef.FieldPath); len(matches) == 2 {
+		container = matches[1]
+	}
+	return objRef.Name + "." + container + suffix
+}
+
+func (o MilvusLogsOptions) GetObjRequest(f cmdutil.Factory, cmd *cobra.Command, labels string, options *corev1.PodLogOptions) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
 	var RESTClientGetter genericclioptions.RESTClientGetter
 	RESTClientGetter = f
 
@@ -330,7 +447,7 @@ func (o MilvusLogsOptions) GetObjRequest(f cmdutil.Factory, cmd *cobra.Command,
 		return nil, err
 	}
 
-	requests, err := polymorphichelpers.LogsForObjectFn(RESTClientGetter, object, &corev1.PodLogOptions{}, getPodTimeout, false)
+	requests, err := polymorphichelpers.LogsForObjectFn(RESTClientGetter, object, options, getPodTimeout, o.LogsOptions.AllContainers)
 	if err != nil {
 		return nil, err
 	}
@@ -338,20 +455,22 @@ func (o MilvusLogsOptions) GetObjRequest(f cmdutil.Factory, cmd *cobra.Command,
 	return requests, nil
 }
 
-func SavePodLogs(fileName string, request rest.ResponseWrapper) error {
+func SavePodLogs(fileName string, request rest.ResponseWrapper, redactor *Redactor) error {
 	file, err := os.Create(fileName)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	if err := DefaultConsumeRequest(request, file); err != nil {
+	if err := DefaultConsumeRequest(request, file, redactor); err != nil {
 		return err
 	}
 	return nil
 }
 
-func DefaultConsumeRequest(request rest.ResponseWrapper, f *os.File) error {
+// DefaultConsumeRequest writes the logs of the request into the file, masking the secrets of every line
+// when a redactor is given
+func DefaultConsumeRequest(request rest.ResponseWrapper, f *os.File, redactor *Redactor) error {
 	readCloser, err := request.Stream(context.TODO())
 	if err != nil {
 		return err
@@ -361,6 +480,9 @@ func DefaultConsumeRequest(request rest.ResponseWrapper, f *os.File) error {
 	r := bufio.NewReader(readCloser)
 	for {
 		bytes, err := r.ReadBytes('\n')
+		if redactor != nil {
+			bytes = redactor.Redact(filepath.Base(f.Name()), bytes)
+		}
 		if _, err := f.Write(bytes); err != nil {
 			return err
 		}
@@ -394,44 +516,3 @@ func IsDir(path string) bool {
 	}
 	return s.IsDir()
 }
-
-func Zip(srcDir string, zipFileName string) error {
-	if Exists(zipFileName) {
-		return fmt.Errorf("Unable to compress: file %s already exists, remove it first", zipFileName)
-	}
-	// os.RemoveAll(zip_file_name)
-
-	// Create zip file
-	zipFile, _ := os.Create(zipFileName)
-	defer zipFile.Close()
-
-	// Open the zip file
-	archive := zip.NewWriter(zipFile)
-	defer archive.Close()
-
-	filepath.Walk(srcDir, func(path string, info os.FileInfo, _ error) error {
-
-		if path == srcDir {
-			return nil
-		}
-
-		header, _ := zip.FileInfoHeader(info)
-		header.Name = strings.TrimPrefix(path, srcDir+`/`)
-
-		if info.IsDir() {
-			header.Name += `/`
-		} else {
-			header.Method = zip.Deflate
-		}
-
-		writer, _ := archive.CreateHeader(header)
-		if !info.IsDir() {
-			file, _ := os.Open(path)
-			defer file.Close()
-			io.Copy(writer, file)
-		}
-		return nil
-	})
-
-	return nil
-}
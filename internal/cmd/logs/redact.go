@@ -0,0 +1,276 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// Redacted replaces the masked secrets
+	Redacted = "[REDACTED]"
+	// RedactionReportFile lists what was masked next to the redacted logs
+	RedactionReportFile = "redaction-report.json"
+	// minSecretLength keeps the short secret values, which would mask unrelated text, out of the rules
+	minSecretLength = 4
+)
+
+// builtinRules mask the credentials milvus, minio and pulsar may log,
+// only the secret group is masked when a rule has one
+var builtinRules = []struct {
+	name    string
+	pattern string
+}{
+	{"access-key", `(?i)access[_-]?key(?:[_-]?id)?["']?\s*[:=]\s*["']?(?P<secret>[^\s"',;}]+)`},
+	{"secret-key", `(?i)secret[_-]?(?:access[_-]?)?key["']?\s*[:=]\s*["']?(?P<secret>[^\s"',;}]+)`},
+	{"password", `(?i)(?:password|passwd|pwd)["']?\s*[:=]\s*["']?(?P<secret>[^\s"',;}]+)`},
+	{"token", `(?i)token["']?\s*[:=]\s*["']?(?P<secret>[^\s"',;}]+)`},
+	{"bearer-token", `(?i)bearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`},
+	{"aws-access-key-id", `\b(?P<secret>(?:AKIA|ASIA)[0-9A-Z]{16})\b`},
+	{"url-credentials", `[a-zA-Z][a-zA-Z0-9+.-]*://[^:/\s@]+:(?P<secret>[^@/\s]+)@`},
+}
+
+// RedactionRule masks the matches of its pattern, or only its secret group when it has one
+type RedactionRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// BuiltinRules returns the rules of the common credentials
+func BuiltinRules() []RedactionRule {
+	rules := []RedactionRule{}
+	for _, rule := range builtinRules {
+		rules = append(rules, RedactionRule{Name: rule.name, Pattern: regexp.MustCompile(rule.pattern)})
+	}
+	return rules
+}
+
+// LoadRules reads a regular expression per line from the file, the empty lines and the lines
+// starting with # are skipped
+func LoadRules(fileName string) ([]RedactionRule, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rules := []RedactionRule{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pattern, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern at %s:%d: %v", fileName, line, err)
+		}
+		rules = append(rules, RedactionRule{Name: fmt.Sprintf("%s:%d", fileName, line), Pattern: pattern})
+	}
+	return rules, scanner.Err()
+}
+
+// SecretRules returns a rule per value of the secrets referenced by the spec of the milvus instance,
+// the secrets that cannot be read are returned as warnings
+func SecretRules(f cmdutil.Factory, namespace string, instanceName string) ([]RedactionRule, []string, error) {
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := context.TODO()
+	u, err := dynamicClient.Resource(v1beta1.GroupVersion.WithResource("milvuses")).Namespace(namespace).Get(ctx, instanceName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, []string{fmt.Sprintf("milvus %s not found, the values of its secrets are not redacted", instanceName)}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	m := &v1beta1.Milvus{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, m); err != nil {
+		return nil, nil, err
+	}
+
+	rules := []RedactionRule{}
+	warnings := []string{}
+	refs := milvus.SecretRefs(&m.Spec)
+	names := []string{}
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("the values of secret %s are not redacted: %v", name, err))
+			continue
+		}
+		keys := refs[name]
+		if len(keys) == 0 {
+			for key := range secret.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+		}
+		for _, key := range keys {
+			value := strings.TrimSpace(string(secret.Data[key]))
+			// the multi-line values, like certificates, never fit in a log line
+			if len(value) < minSecretLength || strings.Contains(value, "\n") {
+				continue
+			}
+			rules = append(rules, RedactionRule{Name: "secret " + name + "/" + key, Pattern: regexp.MustCompile(regexp.QuoteMeta(value))})
+		}
+	}
+	return rules, warnings, nil
+}
+
+// Redactor masks the secrets in the lines of the saved files and counts what it masked per rule and file
+type Redactor struct {
+	rules    []RedactionRule
+	warnings []string
+
+	mu     sync.Mutex
+	counts map[string]map[string]int
+}
+
+// RedactionReport tells the reviewers of a bundle what was masked, it never holds the secrets
+type RedactionReport struct {
+	Rules    []RuleReport `json:"rules"`
+	Warnings []string     `json:"warnings,omitempty"`
+}
+
+// RuleReport is the number of matches of a rule, in total and per file
+type RuleReport struct {
+	Rule    string         `json:"rule"`
+	Matches int            `json:"matches"`
+	Files   map[string]int `json:"files,omitempty"`
+}
+
+// NewRedactor returns a redactor of the rules, the warnings are copied into its report
+func NewRedactor(rules []RedactionRule, warnings []string) *Redactor {
+	return &Redactor{
+		rules:    rules,
+		warnings: warnings,
+		counts:   map[string]map[string]int{},
+	}
+}
+
+// NewRedactor builds the redactor of the options from the builtin rules, the secrets referenced by
+// the milvus spec and the patterns of --redact-patterns, it is nil when --redact is not set. The secrets
+// that cannot be read are reported as warnings, the other rules still apply
+func (o MilvusLogsOptions) NewRedactor(f cmdutil.Factory) (*Redactor, error) {
+	if !o.Redact {
+		return nil, nil
+	}
+	rules := BuiltinRules()
+	secretRules, warnings, err := SecretRules(f, o.Namespace, o.InstanceName)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("the values of the secrets of milvus %s are not redacted: %v", o.InstanceName, err))
+	}
+	rules = append(rules, secretRules...)
+	if o.RedactPatterns != "" {
+		userRules, err := LoadRules(o.RedactPatterns)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, userRules...)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(o.LogsOptions.ErrOut, "Warning: %s\n", warning)
+	}
+	return NewRedactor(rules, warnings), nil
+}
+
+// Redact masks the secrets of a line of the file
+func (r *Redactor) Redact(file string, line []byte) []byte {
+	for _, rule := range r.rules {
+		matches := rule.Pattern.FindAllSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		group := rule.Pattern.SubexpIndex("secret")
+		redacted := make([]byte, 0, len(line))
+		last, count := 0, 0
+		for _, match := range matches {
+			start, end := match[0], match[1]
+			if group > 0 {
+				start, end = match[2*group], match[2*group+1]
+			}
+			// skip the empty matches and what an earlier rule already masked
+			if start < last || start >= end || string(line[start:end]) == Redacted {
+				continue
+			}
+			redacted = append(redacted, line[last:start]...)
+			redacted = append(redacted, Redacted...)
+			last = end
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		line = append(redacted, line[last:]...)
+
+		r.mu.Lock()
+		if r.counts[rule.Name] == nil {
+			r.counts[rule.Name] = map[string]int{}
+		}
+		r.counts[rule.Name][file] += count
+		r.mu.Unlock()
+	}
+	return line
+}
+
+// RedactAll masks the secrets of every line of the data of the file
+func (r *Redactor) RedactAll(file string, data []byte) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	redacted := make([]byte, 0, len(data))
+	for _, line := range lines {
+		redacted = append(redacted, r.Redact(file, line)...)
+	}
+	return redacted
+}
+
+// Report returns the matches of every rule, including the rules that matched nothing
+func (r *Redactor) Report() RedactionReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := RedactionReport{Rules: []RuleReport{}, Warnings: r.warnings}
+	for _, rule := range r.rules {
+		ruleReport := RuleReport{Rule: rule.Name}
+		for file, count := range r.counts[rule.Name] {
+			if ruleReport.Files == nil {
+				ruleReport.Files = map[string]int{}
+			}
+			ruleReport.Files[file] = count
+			ruleReport.Matches += count
+		}
+		report.Rules = append(report.Rules, ruleReport)
+	}
+	return report
+}
+
+// WriteReport writes the report of the redactor as json
+func (r *Redactor) WriteReport(fileName string) error {
+	data, err := json.MarshalIndent(r.Report(), "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, append(data, '\n'), 0644)
+}
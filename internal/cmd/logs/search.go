@@ -0,0 +1,262 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// LogEntry is a log line of a container, together with the following lines that are not in the milvus
+// format, like the lines of a stack trace
+type LogEntry struct {
+	Component string            `json:"component"`
+	Pod       string            `json:"pod"`
+	Container string            `json:"container"`
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level,omitempty"`
+	Caller    string            `json:"caller,omitempty"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	// Lines are the following lines that belong to the entry
+	Lines []string `json:"lines,omitempty"`
+
+	prefix string
+	// raw are the lines as they are printed, with the kubelet timestamps when --timestamps is set
+	raw []string
+}
+
+// logFilter keeps the entries matching grep at or above the level
+type logFilter struct {
+	grep *regexp.Regexp
+	// level is the index of the lowest level in milvus.LogLevels, -1 keeps every entry
+	level int
+}
+
+func (f logFilter) match(e *LogEntry) bool {
+	if f.level >= 0 {
+		level, err := milvus.ParseLogLevel(e.Level)
+		if err != nil || level < f.level {
+			return false
+		}
+	}
+	if f.grep == nil {
+		return true
+	}
+	for _, line := range e.raw {
+		if f.grep.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// searching tells if the logs are searched and printed instead of saved
+func (o MilvusLogsOptions) searching() bool {
+	return o.Grep != "" || o.Level != "" || o.Output != ""
+}
+
+func (o MilvusLogsOptions) logFilter() (logFilter, error) {
+	filter := logFilter{level: -1}
+	if o.Grep != "" {
+		grep, err := regexp.Compile(o.Grep)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --grep: %v", err)
+		}
+		filter.grep = grep
+	}
+	if o.Level != "" {
+		level, err := milvus.ParseLogLevel(o.Level)
+		if err != nil {
+			return filter, err
+		}
+		filter.level = level
+	}
+	return filter, nil
+}
+
+// SearchLogs prints the log entries of every container of the selected components and dependences
+// that match --grep and --level, merged in timestamp order. The lines that are not in the milvus
+// format are ordered by their kubelet timestamp and have no level
+func (o MilvusLogsOptions) SearchLogs(f cmdutil.Factory) error {
+	targets, err := o.logTargets()
+	if err != nil {
+		return err
+	}
+	filter, err := o.logFilter()
+	if err != nil {
+		return err
+	}
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	options, err := o.LogsOptions.ToLogOptions()
+	if err != nil {
+		return err
+	}
+	// the kubelet timestamps order the lines that have none
+	timestamps := options.Timestamps
+	options.Timestamps = true
+	color := o.Output == "" && term.IsTerminal(o.LogsOptions.Out)
+
+	ctx := context.TODO()
+	sem := make(chan struct{}, o.Parallel)
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	// streams keeps the entries of every container in the listing order so that the merge is stable
+	streams := [][]*LogEntry{}
+	seen := map[string]bool{}
+	for _, target := range targets {
+		pods, err := clientset.CoreV1().Pods(o.Namespace).List(ctx, metav1.ListOptions{LabelSelector: target.selector})
+		if err != nil {
+			fmt.Fprintf(o.LogsOptions.ErrOut, "error listing the pods of %s: %v\n", target.selector, err)
+			continue
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			component := target.component(pod)
+			for _, container := range pod.Spec.Containers {
+				key := pod.Name + "/" + container.Name
+				if seen[key] || (o.LogsOptions.Container != "" && container.Name != o.LogsOptions.Container) {
+					continue
+				}
+				seen[key] = true
+				template := LogEntry{
+					Component: component,
+					Pod:       pod.Name,
+					Container: container.Name,
+					prefix:    logPrefix(component, pod, container.Name, color),
+				}
+				podOptions := options.DeepCopy()
+				podOptions.Container = container.Name
+				streams = append(streams, nil)
+				index := len(streams) - 1
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+
+					entries, err := readEntries(ctx, clientset, o.Namespace, podOptions, template, timestamps, filter)
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						fmt.Fprintf(o.LogsOptions.ErrOut, "error reading the logs of %s: %v\n", key, err)
+					}
+					streams[index] = entries
+				}()
+			}
+		}
+	}
+	wg.Wait()
+
+	entries := []*LogEntry{}
+	for _, stream := range streams {
+		entries = append(entries, stream...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return writeEntries(o.LogsOptions.Out, entries, o.Output)
+}
+
+// readEntries reads the logs of a container, the lines of the requested logs start with the kubelet timestamp
+// which is kept in the raw lines when keepTimestamps is set
+func readEntries(ctx context.Context, clientset kubernetes.Interface, namespace string, options *corev1.PodLogOptions, template LogEntry, keepTimestamps bool, filter logFilter) ([]*LogEntry, error) {
+	readCloser, err := clientset.CoreV1().Pods(namespace).GetLogs(template.Pod, options).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer readCloser.Close()
+	return scanEntries(readCloser, template, keepTimestamps, filter)
+}
+
+// scanEntries groups the lines of a container log into entries, the lines that are not in the milvus
+// format are attached to the previous milvus entry
+func scanEntries(reader io.Reader, template LogEntry, keepTimestamps bool, filter logFilter) ([]*LogEntry, error) {
+	entries := []*LogEntry{}
+	var current *LogEntry
+	flush := func() {
+		if current != nil && filter.match(current) {
+			entries = append(entries, current)
+		}
+		current = nil
+	}
+	r := bufio.NewReader(reader)
+	for {
+		line, err := r.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			raw, text := line, line
+			var kubeletTime time.Time
+			if i := strings.IndexByte(line, ' '); i > 0 {
+				if t, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+					kubeletTime, text = t, line[i+1:]
+				}
+			}
+			if !keepTimestamps {
+				raw = text
+			}
+
+			if parsed, ok := milvus.ParseLogLine(text); ok {
+				flush()
+				entry := template
+				entry.Time, entry.Level, entry.Caller, entry.Message, entry.Fields = parsed.Time, parsed.Level, parsed.Caller, parsed.Message, parsed.Fields
+				entry.raw = []string{raw}
+				current = &entry
+			} else if current != nil && current.Level != "" {
+				// a line of the stack trace or of the multi-line message of the previous entry
+				current.Lines = append(current.Lines, text)
+				current.raw = append(current.raw, raw)
+			} else {
+				flush()
+				entry := template
+				entry.Time, entry.Message = kubeletTime, text
+				entry.raw = []string{raw}
+				current = &entry
+			}
+		}
+		if err != nil {
+			flush()
+			if err != io.EOF {
+				return entries, err
+			}
+			return entries, nil
+		}
+	}
+}
+
+// writeEntries prints the entries prefixed by component/pod, or as a json object per line with the json output
+func writeEntries(out io.Writer, entries []*LogEntry, output string) error {
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, entry := range entries {
+		for _, line := range entry.raw {
+			if _, err := fmt.Fprintf(out, "%s %s\n", entry.prefix, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// a milvus panic with its stack trace, read with the kubelet timestamps
+const panicLog = `2022-08-01T08:39:02.100000000Z [2022/08/01 08:39:02.100 +00:00] [INFO] [querynode/query_node.go:200] ["query node start"]
+2022-08-01T08:39:02.200000000Z [2022/08/01 08:39:02.200 +00:00] [ERROR] [querynode/segment_loader.go:316] ["failed to load segment"] [segmentID=434]
+2022-08-01T08:39:02.200000000Z panic: segment 434 not found
+2022-08-01T08:39:02.200000000Z goroutine 1 [running]:
+2022-08-01T08:39:02.200000000Z main.main()
+2022-08-01T08:39:02.300000000Z [2022/08/01 08:39:02.300 +00:00] [WARNING] [querynode/flow_graph.go:90] ["flow graph closed"]
+`
+
+func TestScanEntries(t *testing.T) {
+	stack := []string{"panic: segment 434 not found", "goroutine 1 [running]:", "main.main()"}
+	tests := []struct {
+		name           string
+		log            string
+		keepTimestamps bool
+		filter         logFilter
+		// want are the messages of the entries with the lines attached to them
+		want map[string][]string
+		// raw are the printed lines of the ERROR entry
+		raw []string
+	}{
+		{
+			name:   "stack trace is attached to the previous entry",
+			log:    panicLog,
+			filter: logFilter{level: -1},
+			want:   map[string][]string{"query node start": nil, "failed to load segment": stack, "flow graph closed": nil},
+			raw:    append([]string{`[2022/08/01 08:39:02.200 +00:00] [ERROR] [querynode/segment_loader.go:316] ["failed to load segment"] [segmentID=434]`}, stack...),
+		},
+		{
+			name:           "kubelet timestamps are kept",
+			log:            panicLog,
+			keepTimestamps: true,
+			filter:         logFilter{level: 3},
+			want:           map[string][]string{"failed to load segment": stack},
+			raw: []string{
+				`2022-08-01T08:39:02.200000000Z [2022/08/01 08:39:02.200 +00:00] [ERROR] [querynode/segment_loader.go:316] ["failed to load segment"] [segmentID=434]`,
+				"2022-08-01T08:39:02.200000000Z panic: segment 434 not found",
+				"2022-08-01T08:39:02.200000000Z goroutine 1 [running]:",
+				"2022-08-01T08:39:02.200000000Z main.main()",
+			},
+		},
+		{
+			name:   "warning is kept by --level warn",
+			log:    panicLog,
+			filter: logFilter{level: 2},
+			want:   map[string][]string{"failed to load segment": stack, "flow graph closed": nil},
+		},
+		{
+			name:   "grep matches the attached lines",
+			log:    panicLog,
+			filter: logFilter{grep: regexp.MustCompile("goroutine"), level: -1},
+			want:   map[string][]string{"failed to load segment": stack},
+		},
+		{
+			name:   "lines before the first milvus entry are entries of their own",
+			log:    "2022-08-01T08:39:02.000000000Z starting milvus\n" + panicLog,
+			filter: logFilter{grep: regexp.MustCompile("starting"), level: -1},
+			want:   map[string][]string{"starting milvus": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := scanEntries(strings.NewReader(tt.log), LogEntry{Pod: "my-release-milvus-querynode-0"}, tt.keepTimestamps, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string][]string{}
+			for _, entry := range entries {
+				got[entry.Message] = entry.Lines
+				if entry.Pod != "my-release-milvus-querynode-0" {
+					t.Errorf("entry %q lost the pod of the template", entry.Message)
+				}
+				if entry.Level == "ERROR" && tt.raw != nil && !reflect.DeepEqual(entry.raw, tt.raw) {
+					t.Errorf("raw = %q, want %q", entry.raw, tt.raw)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
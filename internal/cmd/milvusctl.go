@@ -10,11 +10,16 @@ import (
 	"github.com/milvus-io/milvusctl/internal/cmd/create"
 	"github.com/milvus-io/milvusctl/internal/cmd/delete"
 	"github.com/milvus-io/milvusctl/internal/cmd/describe"
+	"github.com/milvus-io/milvusctl/internal/cmd/etcd"
 	ctlexec "github.com/milvus-io/milvusctl/internal/cmd/exec"
 	"github.com/milvus-io/milvusctl/internal/cmd/get"
 	"github.com/milvus-io/milvusctl/internal/cmd/logs"
 	"github.com/milvus-io/milvusctl/internal/cmd/operator"
 	"github.com/milvus-io/milvusctl/internal/cmd/portforward"
+	"github.com/milvus-io/milvusctl/internal/cmd/protect"
+	"github.com/milvus-io/milvusctl/internal/cmd/status"
+	"github.com/milvus-io/milvusctl/internal/cmd/supportbundle"
+	"github.com/milvus-io/milvusctl/internal/cmd/template"
 	"github.com/milvus-io/milvusctl/internal/cmd/update"
 	"github.com/spf13/cobra"
 	"helm.sh/helm/v3/pkg/action"
@@ -73,11 +78,17 @@ Find more information at:
 	milvusCmd.AddCommand(portforward.NewPortForwardCmd(f, o.IOStreams))
 	milvusCmd.AddCommand(delete.NewMilvusDeleteCmd(f, o.IOStreams, client))
 	milvusCmd.AddCommand(update.NewMilvusUpdateCmd(f, o.IOStreams, client))
+	milvusCmd.AddCommand(protect.NewMilvusProtectCmd(f, o.IOStreams, client))
+	milvusCmd.AddCommand(protect.NewMilvusUnprotectCmd(f, o.IOStreams, client))
 	milvusCmd.AddCommand(logs.NewMilvusLogsCmd(f, o.IOStreams))
 	milvusCmd.AddCommand(describe.NewMilvusDescribeCmd(f, o.IOStreams, client))
+	milvusCmd.AddCommand(status.NewMilvusStatusCmd(f, o.IOStreams, client))
+	milvusCmd.AddCommand(supportbundle.NewSupportBundleCmd(f, o.IOStreams, client))
 	milvusCmd.AddCommand(ctlexec.NewMilvusExecCmd(f, o.IOStreams))
+	milvusCmd.AddCommand(etcd.NewMilvusEtcdCmd(f, o.IOStreams))
 	milvusCmd.AddCommand(cp.NewMilvusCpCmd(f, o.IOStreams))
 	milvusCmd.AddCommand(get.NewMilvusGetCmd("milvusctl", f, o.IOStreams))
+	milvusCmd.AddCommand(template.NewTemplateCmd(o.IOStreams))
 	return milvusCmd
 }
 
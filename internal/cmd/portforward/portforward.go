@@ -3,9 +3,13 @@ package portforward
 import (
 	"context"
 	"fmt"
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -17,19 +21,24 @@ import (
 	"k8s.io/kubectl/pkg/polymorphichelpers"
 	"k8s.io/kubectl/pkg/util"
 	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/podutils"
 	"k8s.io/kubectl/pkg/util/templates"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // PortForwardOptions contains all the options for running the port-forward cli command.
 type PortForwardOptions struct {
-	Namespace     string
+	Namespace string
+	// Name tells what PodName is, like the milvus proxy, it is printed before forwarding when set
+	Name          string
 	PodName       string
 	RESTClient    *restclient.RESTClient
 	Config        *restclient.Config
@@ -39,17 +48,63 @@ type PortForwardOptions struct {
 	PortForwarder portForwarder
 	StopChannel   chan struct{}
 	ReadyChannel  chan struct{}
+	// Forwards are the pods of the milvus dependences forwarded in the same session
+	Forwards     []Forward
+	MinioConsole bool
+	Etcd         bool
+	PulsarAdmin  bool
+	// Reconnect forwards the same local ports to a new pod of the resource when the forwarded pod goes away
+	Reconnect bool
+
+	genericclioptions.IOStreams
+
+	// resolve selects the pod of the resource again, with the ports to forward to it
+	resolve func() (*corev1.Pod, []string, error)
+}
+
+// Forward is a pod whose ports are forwarded along with the ports of PodName
+type Forward struct {
+	Name    string
+	PodName string
+	Ports   []string
+
+	resolve func() (*corev1.Pod, []string, error)
 }
 
+var (
+	// reconnectBackoff is the first delay before resolving a lost pod again, it doubles up to reconnectMaxBackoff
+	reconnectBackoff    = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+	// defaultMilvusPorts are the grpc and the metrics ports of the proxy or standalone service
+	defaultMilvusPorts = []string{"19530", "9091"}
+	// dependenceForwards are the dependence pods forwarded by the flags of the milvus/NAME form
+	dependenceForwards = []struct {
+		name       string
+		dependence string
+		// selector narrows the pods of the dependence, like the brokers of pulsar
+		selector string
+		port     string
+	}{
+		{name: "minio console", dependence: "minio", port: "9001"},
+		{name: "etcd", dependence: "etcd", port: "2379"},
+		{name: "pulsar admin", dependence: "pulsar", selector: "component=broker", port: "8080"},
+	}
+)
+
 var (
 	portforwardLong = templates.LongDesc(i18n.T(`
                 Forward one or more local ports to a pod.
 
                 Use resource type/name such as deployment/mydeployment to select a pod. Resource type defaults to 'pod' if omitted.
 
+                Use milvus/NAME to select a pod of the proxy, or standalone, service of a milvus instance. Its grpc
+                port 19530 and metrics port 9091 are forwarded when no port is given, and --minio-console, --etcd
+                and --pulsar-admin forward the ports of its in-cluster dependences in the same session.
+
                 If there are multiple pods matching the criteria, a pod will be selected automatically. The
                 forwarding session ends when the selected pod terminates, and a rerun of the command is needed
-                to resume forwarding.`))
+                to resume forwarding, unless --reconnect is set: the resource is then resolved to a new running
+                pod, with backoff, and the same local ports are forwarded to it.`))
 	portforwardExample = templates.Examples(i18n.T(`
 		# Listen on ports 5000 and 6000 locally, forwarding data to/from ports 5000 and 6000 in the pod
 		kubectl port-forward pod/mypod 5000 6000
@@ -70,7 +125,19 @@ var (
 		kubectl port-forward --address localhost,10.19.21.23 pod/mypod 8888:5000
 
 		# Listen on a random port locally, forwarding to 5000 in the pod
-		kubectl port-forward pod/mypod :5000`))
+		kubectl port-forward pod/mypod :5000
+
+		# Listen on ports 19530 and 9091 locally, forwarding to the grpc and metrics ports of the proxy of milvus my-release
+		milvusctl port-forward milvus/my-release
+
+		# Also forward the minio console on 9001, etcd on 2379 and the pulsar admin api on 8080
+		milvusctl port-forward milvus/my-release --minio-console --etcd --pulsar-admin
+
+		# Listen on port 29530 locally, forwarding to the grpc port of the proxy of milvus my-release
+		milvusctl port-forward milvus/my-release 29530:19530
+
+		# Keep forwarding to the proxy of milvus my-release across its restarts and rolling upgrades
+		milvusctl port-forward milvus/my-release --reconnect`))
 )
 
 const (
@@ -83,9 +150,10 @@ func NewPortForwardCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *
 		PortForwarder: &defaultPortForwarder{
 			IOStreams: streams,
 		},
+		IOStreams: streams,
 	}
 	cmd := &cobra.Command{
-		Use:                   "port-forward TYPE/NAME [options] [LOCAL_PORT:]REMOTE_PORT [...[LOCAL_PORT_N:]REMOTE_PORT_N]",
+		Use:                   "port-forward (TYPE/NAME | milvus/NAME) [options] [LOCAL_PORT:]REMOTE_PORT [...[LOCAL_PORT_N:]REMOTE_PORT_N]",
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("Forward one or more local ports to a pod"),
 		Long:                  portforwardLong,
@@ -99,6 +167,10 @@ func NewPortForwardCmd(f cmdutil.Factory, streams genericclioptions.IOStreams) *
 	}
 	cmdutil.AddPodRunningTimeoutFlag(cmd, defaultPodPortForwardWaitTimeout)
 	cmd.Flags().StringSliceVar(&opts.Address, "address", []string{"localhost"}, "Addresses to listen on (comma separated). Only accepts IP addresses or localhost as a value. When localhost is supplied, kubectl will try to bind on both 127.0.0.1 and ::1 and will fail if neither of these addresses are available to bind.")
+	cmd.Flags().BoolVar(&opts.MinioConsole, "minio-console", opts.MinioConsole, "With milvus/NAME, also forward the console port 9001 of the in-cluster minio")
+	cmd.Flags().BoolVar(&opts.Etcd, "etcd", opts.Etcd, "With milvus/NAME, also forward the client port 2379 of the in-cluster etcd")
+	cmd.Flags().BoolVar(&opts.PulsarAdmin, "pulsar-admin", opts.PulsarAdmin, "With milvus/NAME, also forward the admin port 8080 of a broker of the in-cluster pulsar")
+	cmd.Flags().BoolVar(&opts.Reconnect, "reconnect", opts.Reconnect, "If true, forward the same local ports to a new running pod of the resource when the forwarded pod goes away")
 	// TODO support UID
 	return cmd
 
@@ -273,7 +345,13 @@ func checkUDPPortInPod(ports []string, pod *corev1.Pod) error {
 // Complete completes all the required options for port-forward cmd.
 func (o *PortForwardOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
 	var err error
-	if len(args) < 2 {
+	instanceName := ""
+	if len(args) > 0 && strings.HasPrefix(args[0], "milvus/") {
+		instanceName = strings.TrimPrefix(args[0], "milvus/")
+	} else if o.MinioConsole || o.Etcd || o.PulsarAdmin {
+		return cmdutil.UsageErrorf(cmd, "--minio-console, --etcd and --pulsar-admin can only be used with milvus/NAME")
+	}
+	if len(args) < 2 && instanceName == "" {
 		return cmdutil.UsageErrorf(cmd, "TYPE/NAME and list of ports are required for port-forward")
 	}
 
@@ -282,16 +360,48 @@ func (o *PortForwardOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, arg
 		return err
 	}
 
-	builder := f.NewBuilder().
-		WithScheme(scheme.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
-		ContinueOnError().
-		NamespaceParam(o.Namespace).DefaultNamespace()
-
 	getPodTimeout, err := cmdutil.GetPodRunningTimeoutFlag(cmd)
 	if err != nil {
 		return cmdutil.UsageErrorf(cmd, err.Error())
 	}
 
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.PodClient = clientset.CoreV1()
+
+	if instanceName != "" {
+		err = o.completeMilvus(f, instanceName, args[1:], getPodTimeout)
+	} else {
+		err = o.completeResource(f, args, getPodTimeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	o.Config, err = f.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	o.RESTClient, err = f.RESTClient()
+	if err != nil {
+		return err
+	}
+
+	o.StopChannel = make(chan struct{}, 1)
+	o.ReadyChannel = make(chan struct{})
+	return nil
+}
+
+// completeResource selects the pod of the TYPE/NAME resource and translates the ports of a service to the ports of the pod
+func (o *PortForwardOptions) completeResource(f cmdutil.Factory, args []string, getPodTimeout time.Duration) error {
+	builder := f.NewBuilder().
+		WithScheme(scheme.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace()
+
 	resourceName := args[0]
 	builder.ResourceNames("pods", resourceName)
 
@@ -300,53 +410,133 @@ func (o *PortForwardOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, arg
 		return err
 	}
 
-	forwardablePod, err := polymorphichelpers.AttachablePodForObjectFn(f, obj, getPodTimeout)
+	o.resolve = func() (*corev1.Pod, []string, error) {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			// the pod of a statefulset comes back under the same name
+			current, err := o.PodClient.Pods(o.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resolvePorts(f, current, args[1:], getPodTimeout)
+		}
+		return resolvePorts(f, obj, args[1:], getPodTimeout)
+	}
+	forwardablePod, ports, err := o.resolve()
 	if err != nil {
 		return err
 	}
-
 	o.PodName = forwardablePod.Name
+	o.Ports = ports
+	return nil
+}
+
+// resolvePorts selects the pod of the object and converts the ports to the ports of the pod
+func resolvePorts(f cmdutil.Factory, obj runtime.Object, ports []string, getPodTimeout time.Duration) (*corev1.Pod, []string, error) {
+	forwardablePod, err := polymorphichelpers.AttachablePodForObjectFn(f, obj, getPodTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// handle service port mapping to target port if needed
 	switch t := obj.(type) {
 	case *corev1.Service:
-		err = checkUDPPortInService(args[1:], t)
+		err = checkUDPPortInService(ports, t)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
-		o.Ports, err = translateServicePortToTargetPort(args[1:], *t, *forwardablePod)
+		ports, err = translateServicePortToTargetPort(ports, *t, *forwardablePod)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	default:
-		err = checkUDPPortInPod(args[1:], forwardablePod)
+		err = checkUDPPortInPod(ports, forwardablePod)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
-		o.Ports, err = convertPodNamedPortToNumber(args[1:], *forwardablePod)
+		ports, err = convertPodNamedPortToNumber(ports, *forwardablePod)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
+	return forwardablePod, ports, nil
+}
 
-	clientset, err := f.KubernetesClientSet()
+// completeMilvus selects a pod of the proxy, or standalone, service of the milvus instance, which is the
+// <name>-milvus service of the operator, and the pods of the dependences whose flags are set
+func (o *PortForwardOptions) completeMilvus(f cmdutil.Factory, instanceName string, ports []string, getPodTimeout time.Duration) error {
+	dynamicClient, err := f.DynamicClient()
 	if err != nil {
 		return err
 	}
-
-	o.PodClient = clientset.CoreV1()
-
-	o.Config, err = f.ToRESTConfig()
+	clientset, err := f.KubernetesClientSet()
 	if err != nil {
 		return err
 	}
-	o.RESTClient, err = f.RESTClient()
+	ctx := context.TODO()
+	u, err := dynamicClient.Resource(v1beta1.GroupVersion.WithResource("milvuses")).Namespace(o.Namespace).Get(ctx, instanceName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", instanceName, o.Namespace)
+	}
 	if err != nil {
 		return err
 	}
+	m := &v1beta1.Milvus{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, m); err != nil {
+		return err
+	}
 
-	o.StopChannel = make(chan struct{}, 1)
-	o.ReadyChannel = make(chan struct{})
+	component := "standalone"
+	if m.Spec.Mode == v1beta1.MilvusModeCluster {
+		component = "proxy"
+	}
+	if len(ports) == 0 {
+		ports = defaultMilvusPorts
+	}
+	o.resolve = func() (*corev1.Pod, []string, error) {
+		service, err := clientset.CoreV1().Services(o.Namespace).Get(ctx, instanceName+"-milvus", metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get the %s service of milvus %s: %v", component, instanceName, err)
+		}
+		return resolvePorts(f, service, ports, getPodTimeout)
+	}
+	pod, ports, err := o.resolve()
+	if err != nil {
+		return err
+	}
+	o.Name = "milvus " + component
+	o.PodName = pod.Name
+	o.Ports = ports
+
+	enabled := map[string]bool{"minio": o.MinioConsole, "etcd": o.Etcd, "pulsar": o.PulsarAdmin}
+	for _, forward := range dependenceForwards {
+		if !enabled[forward.dependence] {
+			continue
+		}
+		if milvus.InClusterDependence(&m.Spec, forward.dependence) == nil {
+			return fmt.Errorf("the %s of milvus %s is not deployed in the cluster", forward.dependence, instanceName)
+		}
+		selector, err := milvus.DependenceSelector(instanceName, forward.dependence)
+		if err != nil {
+			return err
+		}
+		if forward.selector != "" {
+			selector += ", " + forward.selector
+		}
+		dependence, port := forward.dependence, forward.port
+		resolve := func() (*corev1.Pod, []string, error) {
+			sortBy := func(pods []*corev1.Pod) sort.Interface { return sort.Reverse(podutils.ActivePods(pods)) }
+			pod, _, err := polymorphichelpers.GetFirstPod(clientset.CoreV1(), o.Namespace, selector, getPodTimeout, sortBy)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get a pod of the %s of milvus %s: %v", dependence, instanceName, err)
+			}
+			return pod, []string{port}, nil
+		}
+		pod, ports, err := resolve()
+		if err != nil {
+			return err
+		}
+		o.Forwards = append(o.Forwards, Forward{Name: forward.name, PodName: pod.Name, Ports: ports, resolve: resolve})
+	}
 	return nil
 }
 
@@ -363,36 +553,140 @@ func (o PortForwardOptions) Validate() error {
 	if o.PortForwarder == nil || o.PodClient == nil || o.RESTClient == nil || o.Config == nil {
 		return fmt.Errorf("client, client config, restClient, and portforwarder must be provided")
 	}
+
+	if o.Reconnect {
+		for _, port := range o.Ports {
+			if local, _ := splitPort(port); local == "" {
+				return fmt.Errorf("--reconnect needs the local port of %s, a random local port would change on reconnect", port)
+			}
+		}
+	}
 	return nil
 }
 
 // RunPortForward implements all the necessary functionality for port-forward cmd.
+// The ports of the pods of Forwards are forwarded in the same session, which ends when any of them fails.
 func (o PortForwardOptions) RunPortForward() error {
-	pod, err := o.PodClient.Pods(o.Namespace).Get(context.TODO(), o.PodName, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
+	forwards := append([]Forward{{Name: o.Name, PodName: o.PodName, Ports: o.Ports, resolve: o.resolve}}, o.Forwards...)
+	for _, forward := range forwards {
+		pod, err := o.PodClient.Pods(o.Namespace).Get(context.TODO(), forward.PodName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
 
-	if pod.Status.Phase != corev1.PodRunning {
-		return fmt.Errorf("unable to forward port because pod is not running. Current status=%v", pod.Status.Phase)
+		if pod.Status.Phase != corev1.PodRunning {
+			return fmt.Errorf("unable to forward port because pod %s is not running. Current status=%v", pod.Name, pod.Status.Phase)
+		}
 	}
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt)
 	defer signal.Stop(signals)
 
+	stopOnce := sync.Once{}
+	stop := func() {
+		stopOnce.Do(func() {
+			if o.StopChannel != nil {
+				close(o.StopChannel)
+			}
+		})
+	}
 	go func() {
 		<-signals
-		if o.StopChannel != nil {
-			close(o.StopChannel)
-		}
+		stop()
 	}()
 
-	req := o.RESTClient.Post().
-		Resource("pods").
-		Namespace(o.Namespace).
-		Name(pod.Name).
-		SubResource("portforward")
+	errs := make(chan error, len(forwards))
+	for i, forward := range forwards {
+		opts := o
+		opts.PodName, opts.Ports = forward.PodName, forward.Ports
+		if i > 0 {
+			opts.ReadyChannel = make(chan struct{})
+		}
+		if forward.Name != "" && o.Out != nil {
+			fmt.Fprintf(o.Out, "Forwarding the %s pod %s\n", forward.Name, forward.PodName)
+		}
 
-	return o.PortForwarder.ForwardPorts("POST", req.URL(), o)
+		go func(forward Forward) {
+			errs <- opts.forward(forward)
+		}(forward)
+	}
+
+	var err error
+	for range forwards {
+		if forwardErr := <-errs; forwardErr != nil && err == nil {
+			err = forwardErr
+			stop()
+		}
+	}
+	return err
+}
+
+// forward forwards the ports to PodName until the session stops. With --reconnect, when the forwarding ends
+// without being stopped the resource is resolved again, with backoff, and the same ports are forwarded to
+// the new pod
+func (o PortForwardOptions) forward(forward Forward) error {
+	backoff := reconnectBackoff
+	connected := false
+	for {
+		req := o.RESTClient.Post().
+			Resource("pods").
+			Namespace(o.Namespace).
+			Name(o.PodName).
+			SubResource("portforward")
+
+		err := o.PortForwarder.ForwardPorts("POST", req.URL(), o)
+		if !o.Reconnect || forward.resolve == nil || isClosed(o.StopChannel) {
+			return err
+		}
+		if isClosed(o.ReadyChannel) {
+			connected, backoff = true, reconnectBackoff
+		}
+		if !connected {
+			// the ports were never forwarded, like when the local ports are in use
+			return err
+		}
+		if err != nil {
+			fmt.Fprintf(o.ErrOut, "Lost the connection to pod %s: %v\n", o.PodName, err)
+		} else {
+			fmt.Fprintf(o.ErrOut, "Lost the connection to pod %s\n", o.PodName)
+		}
+
+		for {
+			select {
+			case <-o.StopChannel:
+				return nil
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+
+			pod, ports, err := forward.resolve()
+			if err == nil && (pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil) {
+				err = fmt.Errorf("pod %s is not running", pod.Name)
+			}
+			if err != nil {
+				fmt.Fprintf(o.ErrOut, "Unable to reconnect: %v, retrying in %s\n", err, backoff)
+				continue
+			}
+			o.PodName, o.Ports = pod.Name, ports
+			break
+		}
+		if forward.Name != "" {
+			fmt.Fprintf(o.Out, "Reconnecting the %s to pod %s\n", forward.Name, o.PodName)
+		} else {
+			fmt.Fprintf(o.Out, "Reconnecting to pod %s\n", o.PodName)
+		}
+		o.ReadyChannel = make(chan struct{})
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
 }
@@ -0,0 +1,146 @@
+package protect
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	protectExample = templates.Examples(i18n.T(`
+		# Refuse to delete my-release or to recreate its storage until it is unprotected
+		milvusctl protect my-release`))
+
+	unprotectExample = templates.Examples(i18n.T(`
+		# Allow my-release to be deleted again
+		milvusctl unprotect my-release`))
+)
+
+type MilvusProtectOptions struct {
+	Namespace string
+	// By is the kubeconfig user, or the local user, recorded in the protection
+	By string
+	genericclioptions.IOStreams
+}
+
+func NewMilvusProtectCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, client *client.Client) *cobra.Command {
+	o := &MilvusProtectOptions{IOStreams: ioStreams}
+	protectCmd := &cobra.Command{
+		Use:     "protect NAME",
+		Short:   "protect milvus instance against deletion",
+		Long:    "The protect subcommand annotates the milvus instance so that delete and updates recreating its storage refuse to proceed without --force-unprotected",
+		Example: protectExample,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd))
+			cmdutil.CheckErr(o.Run(*client, args[0], true))
+		},
+	}
+	return protectCmd
+}
+
+func NewMilvusUnprotectCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, client *client.Client) *cobra.Command {
+	o := &MilvusProtectOptions{IOStreams: ioStreams}
+	unprotectCmd := &cobra.Command{
+		Use:     "unprotect NAME",
+		Short:   "remove the deletion protection of milvus instance",
+		Example: unprotectExample,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd))
+			cmdutil.CheckErr(o.Run(*client, args[0], false))
+		},
+	}
+	return unprotectCmd
+}
+
+func (o *MilvusProtectOptions) Complete(f cmdutil.Factory, cmd *cobra.Command) error {
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.By = currentUser(f, cmd)
+	return nil
+}
+
+func (o *MilvusProtectOptions) Run(client client.Client, instanceName string, protect bool) error {
+	ctx := context.TODO()
+	m := &v1beta1.Milvus{}
+	namespacedName := types.NamespacedName{
+		Name:      instanceName,
+		Namespace: o.Namespace,
+	}
+	if err := client.Get(ctx, namespacedName, m); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", instanceName, o.Namespace)
+		}
+		return err
+	}
+
+	protection := milvus.GetProtection(m)
+	switch {
+	case protect && protection != nil:
+		fmt.Fprintf(o.Out, "milvus.milvus.io/%s is already %s\n", instanceName, protection)
+		return nil
+	case !protect && protection == nil:
+		fmt.Fprintf(o.Out, "milvus.milvus.io/%s is not protected\n", instanceName)
+		return nil
+	}
+
+	if protect {
+		milvus.Protect(m, o.By, time.Now())
+	} else {
+		milvus.Unprotect(m)
+	}
+	if err := client.Update(ctx, m); err != nil {
+		return err
+	}
+	if protect {
+		fmt.Fprintf(o.Out, "milvus.milvus.io/%s protected\n", instanceName)
+	} else {
+		fmt.Fprintf(o.Out, "milvus.milvus.io/%s unprotected\n", instanceName)
+	}
+	return nil
+}
+
+// currentUser returns the user of the kubeconfig context, the --user and --context flags override
+// the kubeconfig like they do for the client, or the local user without one
+func currentUser(f cmdutil.Factory, cmd *cobra.Command) string {
+	if authInfo := flagValue(cmd, "user"); authInfo != "" {
+		return authInfo
+	}
+	if config, err := f.ToRawKubeConfigLoader().RawConfig(); err == nil {
+		contextName := config.CurrentContext
+		if name := flagValue(cmd, "context"); name != "" {
+			contextName = name
+		}
+		if kubeContext, ok := config.Contexts[contextName]; ok && kubeContext.AuthInfo != "" {
+			return kubeContext.AuthInfo
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
+
+// flagValue returns the value of the flag, empty when the command does not have it
+func flagValue(cmd *cobra.Command, name string) string {
+	if flag := cmd.Flags().Lookup(name); flag != nil {
+		return flag.Value.String()
+	}
+	return ""
+}
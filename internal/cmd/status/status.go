@@ -0,0 +1,147 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	uexec "k8s.io/utils/exec"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	statusLong = templates.LongDesc(i18n.T(`
+		Summarize the health of a milvus instance.
+		Prints the ready and desired replicas and the recent restarts of every milvus component
+		and in-cluster dependence, the failing conditions of the instance and an overall verdict.
+		The exit code follows the verdict: 0 for HEALTHY, 1 for DEGRADED and 2 for DOWN.
+
+		With --watch the status is redrawn in place whenever the instance, its workloads or its pods
+		change, the crash-looping and not ready pods are highlighted. When the output is not a terminal
+		every change is appended instead.`))
+
+	statusExample = templates.Examples(i18n.T(`
+		# Check if the milvus instance my-release is healthy
+		milvusctl status my-release
+		# Count the restarts of the last 10 minutes only
+		milvusctl status my-release --restart-window 10m
+		# Follow the instance my-release while it is upgraded or scaled
+		milvusctl status my-release --watch`))
+)
+
+type MilvusStatusOptions struct {
+	Namespace     string
+	RestartWindow time.Duration
+	Watch         bool
+	genericclioptions.IOStreams
+}
+
+func NewMilvusStatusCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, client *client.Client) *cobra.Command {
+	o := &MilvusStatusOptions{
+		RestartWindow: milvus.DefaultRestartWindow,
+		IOStreams:     ioStreams,
+	}
+	statusCmd := &cobra.Command{
+		Use:     "status NAME",
+		Short:   "show the health summary of milvus instance",
+		Long:    statusLong,
+		Example: statusExample,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f))
+			if o.Watch {
+				cmdutil.CheckErr(o.RunWatch(f, args[0]))
+				return
+			}
+			cmdutil.CheckErr(o.Run(*client, args[0]))
+		},
+	}
+	statusCmd.Flags().DurationVar(&o.RestartWindow, "restart-window", o.RestartWindow, "The window of the container restarts that are counted")
+	statusCmd.Flags().BoolVarP(&o.Watch, "watch", "w", o.Watch, "After printing the status, watch the instance and redraw it on every change")
+	return statusCmd
+}
+
+func (o *MilvusStatusOptions) Complete(f cmdutil.Factory) error {
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	return err
+}
+
+// Run prints the health summary and exits with the code of the verdict
+func (o *MilvusStatusOptions) Run(client client.Client, instanceName string) error {
+	health, err := milvus.CheckHealth(context.TODO(), client, o.Namespace, instanceName, o.RestartWindow)
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", instanceName, o.Namespace)
+	}
+	if err != nil {
+		return err
+	}
+	if err := WriteHealth(o.Out, health, o.RestartWindow); err != nil {
+		return err
+	}
+	if code := health.Verdict.ExitCode(); code != 0 {
+		// exit silently, the verdict is already printed
+		return uexec.CodeExitError{Err: errors.New(""), Code: code}
+	}
+	return nil
+}
+
+// WriteHealth prints the table of the workloads, the failing conditions and the verdict
+func WriteHealth(out io.Writer, health *milvus.Health, restartWindow time.Duration) error {
+	m := health.Milvus
+	status := string(m.Status.Status)
+	if status == "" {
+		status = "Pending"
+	}
+	endpoint := m.Status.Endpoint
+	if endpoint == "" {
+		endpoint = "<none>"
+	}
+	fmt.Fprintf(out, "Milvus:   %s/%s (%s)\n", m.Namespace, m.Name, mode(m))
+	fmt.Fprintf(out, "Status:   %s\n", status)
+	fmt.Fprintf(out, "Endpoint: %s\n\n", endpoint)
+
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintf(w, "NAME\tKIND\tREADY\tRESTARTS(%s)\n", milvus.ShortDuration(restartWindow))
+	for _, workload := range health.Workloads {
+		ready := fmt.Sprintf("%d/%d", workload.Ready, workload.Desired)
+		if !workload.RolledOut {
+			ready += " (rolling out)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", workload.Name, workload.Kind, ready, workload.Restarts)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(health.FailingConditions) != 0 {
+		fmt.Fprintln(out, "\nFailing conditions:")
+		for _, condition := range health.FailingConditions {
+			fmt.Fprintf(out, "  %s=%s %s: %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+
+	fmt.Fprintf(out, "\nVerdict: %s\n", health.Verdict)
+	for _, reason := range health.Reasons {
+		fmt.Fprintf(out, "  - %s\n", reason)
+	}
+	return nil
+}
+
+func mode(m *v1beta1.Milvus) string {
+	if m.Spec.Mode == "" {
+		return string(v1beta1.MilvusModeStandalone)
+	}
+	return string(m.Spec.Mode)
+}
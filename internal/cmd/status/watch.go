@@ -0,0 +1,324 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+const (
+	colorRed     = "\033[31m"
+	colorYellow  = "\033[33m"
+	colorDefault = "\033[39m"
+	colorReset   = "\033[0m"
+	// clearScreen moves the cursor home and clears the terminal before a redraw
+	clearScreen = "\033[H\033[2J"
+)
+
+var milvusResource = v1beta1.GroupVersion.WithResource("milvuses")
+
+// healthWatcher keeps the informers of the milvus custom resource and of the deployments,
+// statefulsets and pods matched by the selectors of the instance
+type healthWatcher struct {
+	namespace     string
+	name          string
+	restartWindow time.Duration
+
+	milvus       cache.SharedIndexInformer
+	deployments  appslisters.DeploymentLister
+	statefulSets map[string]appslisters.StatefulSetLister
+	pods         []corelisters.PodLister
+	synced       []cache.InformerSynced
+	start        []func(stopCh <-chan struct{})
+}
+
+func newHealthWatcher(clientset kubernetes.Interface, dynamicClient dynamic.Interface, namespace string, instanceName string, restartWindow time.Duration, changed func()) *healthWatcher {
+	w := &healthWatcher{
+		namespace:     namespace,
+		name:          instanceName,
+		restartWindow: restartWindow,
+		statefulSets:  map[string]appslisters.StatefulSetLister{},
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { changed() },
+		UpdateFunc: func(interface{}, interface{}) { changed() },
+		DeleteFunc: func(interface{}) { changed() },
+	}
+
+	milvusFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(dynamicClient, 0, namespace, func(options *metav1.ListOptions) {
+		options.FieldSelector = "metadata.name=" + instanceName
+	})
+	w.milvus = milvusFactory.ForResource(milvusResource).Informer()
+	w.milvus.AddEventHandler(handler)
+	w.synced = append(w.synced, w.milvus.HasSynced)
+	w.start = append(w.start, milvusFactory.Start)
+
+	// the components and every dependence are labeled differently, so each selector gets its own factory
+	newFactory := func(selector string) informers.SharedInformerFactory {
+		factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = selector
+			}))
+		pods := factory.Core().V1().Pods()
+		pods.Informer().AddEventHandler(handler)
+		w.pods = append(w.pods, pods.Lister())
+		w.synced = append(w.synced, pods.Informer().HasSynced)
+		w.start = append(w.start, factory.Start)
+		return factory
+	}
+	deployments := newFactory(milvus.InstanceSelector(instanceName)).Apps().V1().Deployments()
+	deployments.Informer().AddEventHandler(handler)
+	w.deployments = deployments.Lister()
+	w.synced = append(w.synced, deployments.Informer().HasSynced)
+	for _, dependence := range milvus.Dependences {
+		selector, _ := milvus.DependenceSelector(instanceName, dependence)
+		statefulSets := newFactory(selector).Apps().V1().StatefulSets()
+		statefulSets.Informer().AddEventHandler(handler)
+		w.statefulSets[dependence] = statefulSets.Lister()
+		w.synced = append(w.synced, statefulSets.Informer().HasSynced)
+	}
+	return w
+}
+
+// Start starts the informers and blocks until their caches are synced
+func (w *healthWatcher) Start(stopCh <-chan struct{}) error {
+	for _, start := range w.start {
+		start(stopCh)
+	}
+	if !cache.WaitForCacheSync(stopCh, w.synced...) {
+		return fmt.Errorf("failed to sync the caches of milvus %s", w.name)
+	}
+	return nil
+}
+
+// Health judges the health of the instance from the informer caches, it returns nil when the milvus is gone
+func (w *healthWatcher) Health() (*milvus.Health, error) {
+	objects := w.milvus.GetStore().List()
+	if len(objects) == 0 {
+		return nil, nil
+	}
+	u, ok := objects[0].(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", objects[0])
+	}
+	m := &v1beta1.Milvus{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, m); err != nil {
+		return nil, err
+	}
+
+	deployments := []appsv1.Deployment{}
+	list, err := w.deployments.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range list {
+		deployments = append(deployments, *deployment)
+	}
+	statefulSets := map[string][]appsv1.StatefulSet{}
+	for dependence, lister := range w.statefulSets {
+		list, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, statefulSet := range list {
+			statefulSets[dependence] = append(statefulSets[dependence], *statefulSet)
+		}
+	}
+	pods := []corev1.Pod{}
+	for _, lister := range w.pods {
+		list, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range list {
+			pods = append(pods, *pod)
+		}
+	}
+	return milvus.Judge(m, milvus.Workloads(deployments, statefulSets), pods, w.restartWindow, time.Now()), nil
+}
+
+// RunWatch redraws the status of the instance in place whenever the milvus, its workloads or its pods change,
+// the snapshots are appended instead when the output is not a terminal
+func (o *MilvusStatusOptions) RunWatch(f cmdutil.Factory, instanceName string) error {
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+	watcher := newHealthWatcher(clientset, dynamicClient, o.Namespace, instanceName, o.RestartWindow, notify)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if err := watcher.Start(ctx.Done()); err != nil {
+		return err
+	}
+
+	tty := term.IsTerminal(o.Out)
+	last := ""
+	notify()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+		health, err := watcher.Health()
+		if err != nil {
+			return err
+		}
+		if health == nil {
+			if last == "" {
+				return fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", instanceName, o.Namespace)
+			}
+			fmt.Fprintf(o.Out, "milvus.milvus.io/%s is deleted\n", instanceName)
+			return nil
+		}
+
+		buf := &bytes.Buffer{}
+		if err := WriteDashboard(buf, health, o.RestartWindow, tty); err != nil {
+			return err
+		}
+		if buf.String() == last {
+			continue
+		}
+		last = buf.String()
+		if tty {
+			fmt.Fprint(o.Out, clearScreen)
+		}
+		fmt.Fprintf(o.Out, "[%s] ", time.Now().Format("15:04:05"))
+		if _, err := o.Out.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		if !tty {
+			fmt.Fprintln(o.Out)
+		}
+	}
+}
+
+// WriteDashboard prints the compact view of the watch: the verdict, the workloads and their pods,
+// the pods that are crash-looping or not ready are colored when color is set and marked with ! otherwise
+func WriteDashboard(out io.Writer, health *milvus.Health, restartWindow time.Duration, color bool) error {
+	m := health.Milvus
+	status := string(m.Status.Status)
+	if status == "" {
+		status = "Pending"
+	}
+	fmt.Fprintf(out, "milvus %s/%s (%s): %s, %s\n", m.Namespace, m.Name, mode(m), status, health.Verdict)
+
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintf(w, "%sNAME\tKIND\tREADY\tRESTARTS(%s)%s\n", rowColor("", color), milvus.ShortDuration(restartWindow), resetColor(color))
+	for _, workload := range health.Workloads {
+		ready := fmt.Sprintf("%d/%d", workload.Ready, workload.Desired)
+		if !workload.RolledOut {
+			ready += " (rolling out)"
+		}
+		highlight := ""
+		if !workload.IsReady() {
+			highlight = colorYellow
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%d%s\n", rowColor(highlight, color), workload.Name, workload.Kind, ready, workload.Restarts, resetColor(color))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+
+	w = printers.GetNewTabWriter(out)
+	fmt.Fprintf(w, "%sPOD\tREADY\tSTATUS\tRESTARTS%s\n", rowColor("", color), resetColor(color))
+	for _, workload := range health.Workloads {
+		pods := append([]corev1.Pod{}, workload.Pods...)
+		sort.Slice(pods, func(i, j int) bool {
+			return pods[i].Name < pods[j].Name
+		})
+		for i := range pods {
+			pod := &pods[i]
+			ready, total, restarts := podReadiness(pod)
+			reason := milvus.PodReason(pod)
+			highlight := ""
+			switch {
+			case strings.HasSuffix(reason, "CrashLoopBackOff"):
+				highlight = colorRed
+			case ready < total:
+				highlight = colorYellow
+			}
+			name := pod.Name
+			if highlight != "" && !color {
+				name = "!" + name
+			}
+			fmt.Fprintf(w, "%s%s\t%d/%d\t%s\t%d%s\n", rowColor(highlight, color), name, ready, total, reason, restarts, resetColor(color))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, reason := range health.Reasons {
+		fmt.Fprintf(out, "  - %s\n", reason)
+	}
+	return nil
+}
+
+// rowColor starts every row with an escape sequence of the same width so that the columns stay aligned
+func rowColor(highlight string, color bool) string {
+	if !color {
+		return ""
+	}
+	if highlight == "" {
+		return colorDefault
+	}
+	return highlight
+}
+
+// resetColor ends a colored row in a cell of its own
+func resetColor(color bool) string {
+	if !color {
+		return ""
+	}
+	return "\t" + colorReset
+}
+
+func podReadiness(pod *corev1.Pod) (int, int, int32) {
+	ready, restarts := 0, int32(0)
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		restarts += status.RestartCount
+	}
+	return ready, len(pod.Spec.Containers), restarts
+}
@@ -0,0 +1,248 @@
+package supportbundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/internal/cmd/describe"
+	"github.com/milvus-io/milvusctl/internal/cmd/logs"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"github.com/milvus-io/milvusctl/pkg/version"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// specKinds are the kinds of the objects of the instance saved in the bundle
+	specKinds = []string{"deployment", "statefulset", "service", "configmap", "persistentvolumeclaim", "job"}
+	// operatorSelector selects the controller manager of the milvus operator manifests
+	operatorSelector = "control-plane=controller-manager"
+)
+
+// bundle writes the collected parts of a support bundle into its directory
+type bundle struct {
+	dir     string
+	options *SupportBundleOptions
+	client  client.Client
+	// redactor masks the secrets of every file, it is nil with --redact=false
+	redactor *logs.Redactor
+}
+
+func (b *bundle) collectMilvus() error {
+	o := b.options
+	m := &v1beta1.Milvus{}
+	err := b.client.Get(context.TODO(), client.ObjectKey{Namespace: o.Namespace, Name: o.InstanceName}, m)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", o.InstanceName, o.Namespace)
+	}
+	if err != nil {
+		return err
+	}
+	m.SetGroupVersionKind(v1beta1.GroupVersion.WithKind("Milvus"))
+	m.ManagedFields = nil
+	return b.writeYAML("milvus.yaml", m)
+}
+
+func (b *bundle) collectVersion() error {
+	return b.writeFile("version.txt", []byte(version.Get().String()+"\n"))
+}
+
+// collectSpecs saves the objects of the instance and of its in-cluster dependences as specs/<kind>/<name>.yaml
+func (b *bundle) collectSpecs() error {
+	o := b.options
+	allErrs := []error{}
+	for _, kind := range specKinds {
+		objects, err := milvus.ListObjects(context.TODO(), b.client, o.Namespace, o.InstanceName, kind)
+		if err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		for _, object := range objects {
+			// the listed items miss their type meta
+			gvk, err := apiutil.GVKForObject(object, b.client.Scheme())
+			if err != nil {
+				allErrs = append(allErrs, err)
+				continue
+			}
+			object.GetObjectKind().SetGroupVersionKind(gvk)
+			object.SetManagedFields(nil)
+			if err := b.writeYAML(filepath.Join("specs", kind, object.GetName()+".yaml"), object); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// collectReport saves the describe milvus output of every component and dependence and their events
+func (b *bundle) collectReport() error {
+	o := b.options
+	describeOptions := describe.MilvusDescribeOptions{
+		Namespace:    o.Namespace,
+		InstanceName: o.InstanceName,
+		Component:    "all",
+		Dependence:   "all",
+	}
+	report, err := describeOptions.Report(b.client, o.Events)
+	allErrs := []error{}
+	if err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if o.Events {
+		if err := b.writeYAML("events.yaml", report.Events); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	if o.Describe {
+		buf := &bytes.Buffer{}
+		if err := describe.WriteReport(buf, report, true); err != nil {
+			allErrs = append(allErrs, err)
+		}
+		if err := b.writeFile("describe.txt", buf.Bytes()); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// collectNodes saves the nodes hosting the pods of the instance as nodes/<node>.yaml
+func (b *bundle) collectNodes() error {
+	o := b.options
+	pods, err := milvus.ListObjects(context.TODO(), b.client, o.Namespace, o.InstanceName, "pod")
+	if err != nil {
+		return err
+	}
+	nodeNames := map[string]bool{}
+	for _, object := range pods {
+		if pod, ok := object.(*corev1.Pod); ok && pod.Spec.NodeName != "" {
+			nodeNames[pod.Spec.NodeName] = true
+		}
+	}
+	allErrs := []error{}
+	for nodeName := range nodeNames {
+		node := &corev1.Node{}
+		if err := b.client.Get(context.TODO(), client.ObjectKey{Name: nodeName}, node); err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+		node.ManagedFields = nil
+		if err := b.writeYAML(filepath.Join("nodes", nodeName+".yaml"), node); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// collectLogs saves the logs of the components and dependences with the selectors of milvusctl logs into logs/
+func (b *bundle) collectLogs(f cmdutil.Factory, cmd *cobra.Command) error {
+	logsOptions := *b.options.LogsOptions
+	logsOptions.FilePath = b.dir
+	// the logs are saved under FilePath/InstanceName
+	saved := filepath.Join(b.dir, logsOptions.InstanceName)
+	if err := os.MkdirAll(saved, os.ModePerm); err != nil {
+		return err
+	}
+	err := logsOptions.SaveLogs(f, cmd)
+	if renameErr := os.Rename(saved, filepath.Join(b.dir, "logs")); renameErr != nil {
+		return renameErr
+	}
+	return err
+}
+
+// collectOperator saves the deployment, the version and the logs of the milvus operator into operator/
+func (b *bundle) collectOperator(f cmdutil.Factory) error {
+	o := b.options
+	deployments := &appsv1.DeploymentList{}
+	if err := milvus.ListBySelector(context.TODO(), b.client, o.OperatorNamespace, operatorSelector, deployments); err != nil {
+		return err
+	}
+	if len(deployments.Items) == 0 {
+		return fmt.Errorf("no milvus operator found in namespace %s", o.OperatorNamespace)
+	}
+
+	allErrs := []error{}
+	versions := []string{}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			versions = append(versions, fmt.Sprintf("%s/%s: %s (%s)", deployment.Name, container.Name, milvus.ImageVersion(container.Image), container.Image))
+		}
+		deployment.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+		deployment.ManagedFields = nil
+		if err := b.writeYAML(filepath.Join("operator", deployment.Name+".yaml"), deployment); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	sort.Strings(versions)
+	if err := b.writeFile(filepath.Join("operator", "version.txt"), []byte(strings.Join(versions, "\n")+"\n")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	options, err := o.LogsOptions.LogsOptions.ToLogOptions()
+	if err != nil {
+		return err
+	}
+	options.Previous = false
+	for _, deployment := range deployments.Items {
+		selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+		if err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		pods := &corev1.PodList{}
+		if err := milvus.ListBySelector(context.TODO(), b.client, o.OperatorNamespace, selector.String(), pods); err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		for _, pod := range pods.Items {
+			for _, container := range pod.Spec.Containers {
+				podOptions := options.DeepCopy()
+				podOptions.Container = container.Name
+				request := clientset.CoreV1().Pods(o.OperatorNamespace).GetLogs(pod.Name, podOptions)
+				fileName := filepath.Join(b.dir, "operator", pod.Name+"."+container.Name+".log")
+				if err := logs.SavePodLogs(fileName, request, b.redactor); err != nil {
+					allErrs = append(allErrs, err)
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+func (b *bundle) writeYAML(name string, object interface{}) error {
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return b.writeFile(name, data)
+}
+
+func (b *bundle) writeFile(name string, data []byte) error {
+	path := filepath.Join(b.dir, name)
+	if b.redactor != nil {
+		data = b.redactor.RedactAll(filepath.ToSlash(name), data)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,199 @@
+package supportbundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/milvus-io/milvusctl/internal/cmd/logs"
+	"github.com/spf13/cobra"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultOperatorNamespace = "milvus-operator"
+	defaultPodLogsTimeout    = 20 * time.Second
+)
+
+var (
+	supportBundleLong = templates.LongDesc(i18n.T(`
+		Collect everything needed to troubleshoot a milvus instance into a single archive:
+		the milvus custom resource with its status, the yaml of the deployments, statefulsets,
+		services, configmaps, persistent volume claims and jobs of the instance and of its
+		in-cluster dependences, their events, the describe milvus output, the nodes hosting
+		the pods, the logs of the pods, the logs and version of the milvus operator and the
+		version of milvusctl. Every part but the milvus custom resource can be left out.
+		The credentials and the values of the secrets referenced by the milvus spec are masked
+		in every file of the bundle unless --redact=false is set.`))
+
+	supportBundleExample = templates.Examples(i18n.T(`
+		# Save the support bundle of my-release in the current directory
+		milvusctl support-bundle my-release
+		# Only keep the last hour of the logs and skip the node info
+		milvusctl support-bundle my-release --since=1h --nodes=false
+		# Save the bundle in /tmp without any log
+		milvusctl support-bundle my-release --dir /tmp --logs=false --operator-logs=false
+		# Save the bundle as a tar.gz archive
+		milvusctl support-bundle my-release --format=tar.gz
+		# Also mask the patterns of redact.txt, what is masked is listed in redaction-report.json
+		milvusctl support-bundle my-release --redact-patterns=redact.txt`))
+)
+
+type SupportBundleOptions struct {
+	Namespace    string
+	InstanceName string
+	// Dir is where the archive is written
+	Dir string
+	// Format is the format of the bundle, one of zip, tar.gz or dir
+	Format string
+
+	Specs        bool
+	Events       bool
+	Describe     bool
+	Nodes        bool
+	Logs         bool
+	OperatorLogs bool
+	// OperatorNamespace is where the milvus operator is installed
+	OperatorNamespace string
+
+	// LogsOptions collects the logs of the pods of the instance
+	LogsOptions *logs.MilvusLogsOptions
+	genericclioptions.IOStreams
+}
+
+func NewSupportBundleOptions(streams genericclioptions.IOStreams) *SupportBundleOptions {
+	logsOptions := logs.NewMilvusLogsOptions(streams, false)
+	logsOptions.SaveAll = true
+	return &SupportBundleOptions{
+		Dir:               ".",
+		Format:            logs.FormatZip,
+		Specs:             true,
+		Events:            true,
+		Describe:          true,
+		Nodes:             true,
+		Logs:              true,
+		OperatorLogs:      true,
+		OperatorNamespace: defaultOperatorNamespace,
+		LogsOptions:       logsOptions,
+		IOStreams:         streams,
+	}
+}
+
+func NewSupportBundleCmd(f cmdutil.Factory, streams genericclioptions.IOStreams, client *client.Client) *cobra.Command {
+	o := NewSupportBundleOptions(streams)
+	cmd := &cobra.Command{
+		Use:     "support-bundle NAME",
+		Short:   i18n.T("Collect the specs, status, events and logs of milvus instance into an archive"),
+		Long:    supportBundleLong,
+		Example: supportBundleExample,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args))
+			cmdutil.CheckErr(o.Run(f, cmd, *client))
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", o.Dir, "Specify the directory where the support bundle is saved")
+	cmd.Flags().StringVar(&o.Format, "format", o.Format, "The format of the support bundle, one of: zip, tar.gz, dir")
+	cmd.Flags().IntVar(&o.LogsOptions.Parallel, "parallel", o.LogsOptions.Parallel, "The number of pods whose logs are saved at the same time")
+	cmd.Flags().BoolVar(&o.LogsOptions.Redact, "redact", o.LogsOptions.Redact, "If true, mask the credentials and the values of the secrets referenced by the milvus spec in every file of the bundle")
+	cmd.Flags().StringVar(&o.LogsOptions.RedactPatterns, "redact-patterns", o.LogsOptions.RedactPatterns, "A file of regular expressions to mask in the bundle, one per line, only the (?P<secret>...) group is masked when there is one")
+	cmd.Flags().BoolVar(&o.Specs, "specs", o.Specs, "If true, save the yaml of the objects of the instance")
+	cmd.Flags().BoolVar(&o.Events, "events", o.Events, "If true, save the events of the instance")
+	cmd.Flags().BoolVar(&o.Describe, "describe", o.Describe, "If true, save the describe milvus output of every component and dependence")
+	cmd.Flags().BoolVar(&o.Nodes, "nodes", o.Nodes, "If true, save the nodes hosting the pods of the instance")
+	cmd.Flags().BoolVar(&o.Logs, "logs", o.Logs, "If true, save the logs of the pods of the instance")
+	cmd.Flags().BoolVar(&o.OperatorLogs, "operator-logs", o.OperatorLogs, "If true, save the logs and the version of the milvus operator")
+	cmd.Flags().StringVar(&o.OperatorNamespace, "operator-namespace", o.OperatorNamespace, "The namespace of the milvus operator")
+
+	logsOptions := o.LogsOptions.LogsOptions
+	cmd.Flags().BoolVar(&logsOptions.AllContainers, "all-containers", logsOptions.AllContainers, "Save the logs of all containers of the pods")
+	cmd.Flags().BoolVarP(&logsOptions.Previous, "previous", "p", logsOptions.Previous, "If true, also save the logs of the previous instance of the containers in <pod>.previous.log")
+	cmd.Flags().Int64Var(&logsOptions.Tail, "tail", logsOptions.Tail, "Lines of recent log file to save. Defaults to -1, saving all log lines")
+	cmd.Flags().StringVar(&logsOptions.SinceTime, "since-time", logsOptions.SinceTime, i18n.T("Only save logs after a specific date (RFC3339). Defaults to all logs. Only one of since-time / since may be used."))
+	cmd.Flags().DurationVar(&logsOptions.SinceSeconds, "since", logsOptions.SinceSeconds, "Only save logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs. Only one of since-time / since may be used.")
+	cmdutil.AddPodRunningTimeoutFlag(cmd, defaultPodLogsTimeout)
+	return cmd
+}
+
+func (o *SupportBundleOptions) Complete(f cmdutil.Factory, args []string) error {
+	var err error
+	o.InstanceName = args[0]
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	if len(o.LogsOptions.LogsOptions.SinceTime) > 0 && o.LogsOptions.LogsOptions.SinceSeconds != 0 {
+		return fmt.Errorf("at most one of `sinceTime` or `sinceSeconds` may be specified")
+	}
+	if err := logs.ValidateFormat(o.Format); err != nil {
+		return err
+	}
+	if o.LogsOptions.Parallel <= 0 {
+		return fmt.Errorf("--parallel must be greater than 0")
+	}
+	if !logs.IsDir(o.Dir) {
+		return fmt.Errorf("The path %s is not a dir", o.Dir)
+	}
+	o.LogsOptions.Namespace = o.Namespace
+	o.LogsOptions.InstanceName = o.InstanceName
+	return nil
+}
+
+// Run collects the parts of the bundle in a directory and archives it, the parts that fail are
+// reported once the bundle is saved
+func (o *SupportBundleOptions) Run(f cmdutil.Factory, cmd *cobra.Command, client client.Client) error {
+	name := fmt.Sprintf("%s-support-bundle-%s", o.InstanceName, time.Now().Format("20060102150405"))
+	dir := filepath.Join(o.Dir, name)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	redactor, err := o.LogsOptions.NewRedactor(f)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	o.LogsOptions.Redactor = redactor
+	b := &bundle{dir: dir, options: o, client: client, redactor: redactor}
+
+	// without the milvus custom resource there is nothing to troubleshoot
+	if err := b.collectMilvus(); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	allErrs := []error{}
+	collect := func(part string, enabled bool, collector func() error) {
+		if !enabled {
+			return
+		}
+		fmt.Fprintf(o.Out, "collecting %s\n", part)
+		if err := collector(); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("failed to collect %s: %v", part, err))
+		}
+	}
+	collect("milvusctl version", true, b.collectVersion)
+	collect("specs", o.Specs, b.collectSpecs)
+	collect("describe", o.Describe || o.Events, b.collectReport)
+	collect("nodes", o.Nodes, b.collectNodes)
+	collect("logs", o.Logs, func() error { return b.collectLogs(f, cmd) })
+	collect("operator", o.OperatorLogs, func() error { return b.collectOperator(f) })
+	if redactor != nil {
+		if err := redactor.WriteReport(filepath.Join(dir, logs.RedactionReportFile)); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	archive, err := logs.Archive(dir, o.Format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "support bundle of milvus %s saved to %s\n", o.InstanceName, archive)
+	return utilerrors.NewAggregate(allErrs)
+}
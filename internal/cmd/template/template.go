@@ -0,0 +1,127 @@
+package template
+
+import (
+	"fmt"
+	"github.com/milvus-io/milvusctl/deploy"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	templateExample = templates.Examples(i18n.T(`
+		# List the built-in and user templates used by 'milvusctl create -t type -m mode'
+		milvusctl template list
+		# Print the spec of the medium cluster template
+		milvusctl template show medium -m cluster
+		# Print the spec of the prod-ha template with its base templates merged in
+		milvusctl template show prod-ha --template-dir ./templates`))
+)
+
+type TemplateListOptions struct {
+	TemplateDir string
+	genericclioptions.IOStreams
+}
+
+type TemplateShowOptions struct {
+	Mode        string
+	TemplateDir string
+	genericclioptions.IOStreams
+}
+
+func NewTemplateCmd(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	templateCmd := &cobra.Command{
+		Use:     "template",
+		Short:   "show the templates of milvus instance",
+		Example: templateExample,
+		Run:     runHelp,
+	}
+	templateCmd.AddCommand(NewTemplateListCmd(ioStreams))
+	templateCmd.AddCommand(NewTemplateShowCmd(ioStreams))
+	return templateCmd
+}
+
+func NewTemplateListCmd(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := &TemplateListOptions{
+		IOStreams: ioStreams,
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the built-in and user templates",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+	addTemplateDirFlag(listCmd, &o.TemplateDir)
+	return listCmd
+}
+
+func NewTemplateShowCmd(ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := &TemplateShowOptions{
+		IOStreams: ioStreams,
+	}
+	showCmd := &cobra.Command{
+		Use:   "show type [-m mode]",
+		Short: "print the milvus spec of a template",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Run(args[0]))
+		},
+	}
+	showCmd.Flags().StringVarP(&o.Mode, "mode", "m", o.Mode, "use mode parameter to choose milvus standalone or cluster")
+	addTemplateDirFlag(showCmd, &o.TemplateDir)
+	return showCmd
+}
+
+func addTemplateDirFlag(cmd *cobra.Command, templateDir *string) {
+	cmd.Flags().StringVar(templateDir, "template-dir", *templateDir, "the directory searched first for <type>.yaml templates, before $"+deploy.TemplatesEnv+" and ~/.milvusctl/templates")
+}
+
+func (o *TemplateListOptions) Run() error {
+	list, err := deploy.List(deploy.SearchPath(o.TemplateDir))
+	if err != nil {
+		return err
+	}
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintln(w, "NAME\tSOURCE")
+	for _, t := range list {
+		fmt.Fprintf(w, "%s\t%s\n", t.Name, t.Source)
+	}
+	return w.Flush()
+}
+
+func (o *TemplateShowOptions) Run(name string) error {
+	if o.Mode != "cluster" && o.Mode != "" && o.Mode != "standalone" {
+		return fmt.Errorf("Error mode, please specify one of the following modes: 'standalone', 'cluster'")
+	}
+	dirs := deploy.SearchPath(o.TemplateDir)
+	_, data, err := deploy.Find(name, o.Mode, dirs)
+	if err != nil {
+		return err
+	}
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	// print the file as it is unless it is layered on a base
+	if _, ok := raw[deploy.BaseKey]; ok {
+		values, err := deploy.Load(name, o.Mode, dirs)
+		if err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(values); err != nil {
+			return err
+		}
+	}
+	_, err = o.Out.Write(data)
+	return err
+}
+
+func runHelp(cmd *cobra.Command, args []string) {
+	cmd.Help()
+}
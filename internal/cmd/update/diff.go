@@ -0,0 +1,81 @@
+package update
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/kubectl/pkg/util/term"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+	colorReset = "\033[0m"
+)
+
+// SpecDiff returns the unified diff between the yaml of the live and the proposed milvus spec
+func SpecDiff(name string, live, proposed *v1beta1.MilvusSpec) (string, error) {
+	liveYaml, err := yaml.Marshal(live)
+	if err != nil {
+		return "", err
+	}
+	proposedYaml, err := yaml.Marshal(proposed)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(liveYaml)),
+		B:        difflib.SplitLines(string(proposedYaml)),
+		FromFile: "live/" + name,
+		ToFile:   "proposed/" + name,
+		Context:  3,
+	})
+}
+
+// printSpecDiff prints the diff of the spec and reports if there is any change,
+// the diff goes to stderr when the object itself is printed with -o
+func (o *MilvusUpdateOptions) printSpecDiff(live, proposed *v1beta1.Milvus) (bool, error) {
+	diff, err := SpecDiff(live.Name, &live.Spec, &proposed.Spec)
+	if err != nil {
+		return false, err
+	}
+	out := o.ApplyOptions.Out
+	if o.ApplyOptions.PrintFlags.OutputFormat != nil && *o.ApplyOptions.PrintFlags.OutputFormat != "" {
+		out = o.ApplyOptions.ErrOut
+	}
+	if diff == "" {
+		fmt.Fprintf(out, "no changes to the spec of milvus %s\n", live.Name)
+		return false, nil
+	}
+	writeDiff(out, diff, term.IsTerminal(out))
+	return true, nil
+}
+
+func writeDiff(out io.Writer, diff string, color bool) {
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		if !color {
+			fmt.Fprint(out, line)
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Fprint(out, line)
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprint(out, colorGreen+strings.TrimSuffix(line, "\n")+colorReset+"\n")
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprint(out, colorRed+strings.TrimSuffix(line, "\n")+colorReset+"\n")
+		case strings.HasPrefix(line, "@@"):
+			fmt.Fprint(out, colorCyan+strings.TrimSuffix(line, "\n")+colorReset+"\n")
+		default:
+			fmt.Fprint(out, line)
+		}
+	}
+}
@@ -1,37 +1,46 @@
 package update
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
-	pkgerr "github.com/pkg/errors"
+	"github.com/milvus-io/milvusctl/deploy"
+	"github.com/milvus-io/milvusctl/pkg/milvus"
+	"github.com/milvus-io/milvusctl/pkg/override"
 	"github.com/spf13/cobra"
-	"helm.sh/helm/v3/pkg/strvals"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	// metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	// "k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
 	kubectlapply "k8s.io/kubectl/pkg/cmd/apply"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	// "k8s.io/kubectl/pkg/util/i18n"
 	// "k8s.io/kubectl/pkg/util/templates"
 	// "log"
 	// "os"
-	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strings"
 )
 
+var milvusGroupKind = v1beta1.GroupVersion.WithKind("Milvus").GroupKind()
+
 type printFn func(format string, v ...interface{})
 
 type MilvusUpdateOptions struct {
-	Mode           string
-	Type           string
-	Values         []string
-	Namespace      string
-	ApplyOptions   *kubectlapply.ApplyOptions
-	ResouceSetting map[string]interface{}
+	Mode             string
+	Type             string
+	Values           []string
+	Namespace        string
+	ApplyOptions     *kubectlapply.ApplyOptions
+	ResouceSetting   map[string]interface{}
+	Yes              bool
+	DiffOnly         bool
+	ForceUnprotected bool
 }
 
 func NewMilvusUpdateOptions(ioStreams genericclioptions.IOStreams) *MilvusUpdateOptions {
@@ -43,21 +52,21 @@ func NewMilvusUpdateOptions(ioStreams genericclioptions.IOStreams) *MilvusUpdate
 	}
 }
 
-func NewMilvusUpdateCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, client *client.Client) *cobra.Command {
+func NewMilvusUpdateCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams, c *client.Client) *cobra.Command {
 	o := NewMilvusUpdateOptions(ioStreams)
 	// o.ApplyOptions.cmdBaseName = baseName
 
 	updateCmd := &cobra.Command{
 		Use:   "update instance_name {-f filename | -t type -m model --set [options]}",
 		Short: "update milvus instance in kubernetes cluster",
-		Long:  "The update subcommand updates the milvus configuration",
+		Long:  "The update subcommand updates the milvus configuration, it prints the diff of the milvus spec and asks for confirmation before applying it",
 		Args:  cobra.MaximumNArgs(1),
 
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(o.Complete(f, cmd))
 			cmdutil.CheckErr(o.validateArgs(cmd, args))
 			cmdutil.CheckErr(o.validatePruneAll(o.ApplyOptions.Prune, o.ApplyOptions.All, o.ApplyOptions.Selector))
-			cmdutil.CheckErr(o.Run(f, cmd, client, args))
+			cmdutil.CheckErr(o.Run(f, cmd, c, args))
 		},
 	}
 
@@ -74,6 +83,12 @@ func NewMilvusUpdateCmd(f cmdutil.Factory, ioStreams genericclioptions.IOStreams
 	// updateCmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "use type parameter to choose install namespace")
 	updateCmd.Flags().StringVarP(&o.Type, "type", "t", o.Type, "use type parameter to choose milvus cluster minimal,medium or large")
 	updateCmd.Flags().StringArrayVar(&o.Values, "set", []string{}, "the resource requirement requests for milvus cluster")
+	updateCmd.Flags().BoolVarP(&o.Yes, "yes", "y", o.Yes, "If true, apply the changes without asking for confirmation")
+	updateCmd.Flags().BoolVar(&o.DiffOnly, "diff-only", o.DiffOnly, "If true, only print the diff of the milvus spec and exit with code 1 when there are changes")
+	updateCmd.Flags().BoolVar(&o.ForceUnprotected, "force-unprotected", o.ForceUnprotected, "If true, apply changes that recreate the storage of a milvus instance protected by milvusctl protect")
+	// --wait and --timeout come with the apply delete flags, for an instance update they wait for it to become Healthy
+	updateCmd.Flags().Lookup("wait").Usage = "If true, wait for the updated milvus instance to become Healthy and print the progress of its components. With -f, wait for the milvus instances of the files to become Healthy and for pruned resources to be gone"
+	updateCmd.Flags().Lookup("timeout").Usage = "The length of time to wait, zero means 10m for a milvus instance and determine a timeout from the size of the object with -f"
 
 	return updateCmd
 }
@@ -82,7 +97,19 @@ func (o *MilvusUpdateOptions) Complete(f cmdutil.Factory, cmd *cobra.Command) er
 	var err error
 	if len(*o.ApplyOptions.DeleteFlags.FileNameFlags.Filenames) == 0 {
 		o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
-		return err
+		if err != nil {
+			return err
+		}
+		o.ApplyOptions.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+		if err != nil {
+			return err
+		}
+		o.ApplyOptions.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
+			o.ApplyOptions.PrintFlags.NamePrintFlags.Operation = operation
+			cmdutil.PrintFlagsWithDryRunStrategy(o.ApplyOptions.PrintFlags, o.ApplyOptions.DryRunStrategy)
+			return o.ApplyOptions.PrintFlags.ToPrinter()
+		}
+		return nil
 	}
 
 	err = o.ApplyOptions.Complete(f, cmd)
@@ -93,12 +120,13 @@ func (o *MilvusUpdateOptions) Complete(f cmdutil.Factory, cmd *cobra.Command) er
 }
 
 func (o *MilvusUpdateOptions) validateArgs(cmd *cobra.Command, args []string) error {
+	if *o.ApplyOptions.DeleteFlags.Wait && o.ApplyOptions.DryRunStrategy != cmdutil.DryRunNone {
+		return fmt.Errorf("--wait can not be used with --dry-run")
+	}
 	if len(o.Values) > 0 {
-		base := map[string]interface{}{}
-		for _, value := range o.Values {
-			if err := strvals.ParseInto(value, base); err != nil {
-				return pkgerr.Wrap(err, "failed parsing --set data")
-			}
+		base, err := override.Parse(o.Values)
+		if err != nil {
+			return err
 		}
 		o.ResouceSetting = base
 	}
@@ -115,207 +143,250 @@ func (o *MilvusUpdateOptions) validatePruneAll(prune, all bool, selector string)
 	return nil
 }
 
-func (o *MilvusUpdateOptions) Run(f cmdutil.Factory, cmd *cobra.Command, client *client.Client, args []string) error {
+func (o *MilvusUpdateOptions) Run(f cmdutil.Factory, cmd *cobra.Command, c *client.Client, args []string) error {
 
 	if len(*o.ApplyOptions.DeleteFlags.FileNameFlags.Filenames) != 0 {
-		if err := o.ApplyOptions.Run(); err != nil {
-			return err
-		}
-		return nil
+		return o.runApply(*c)
 	}
 
 	if len(args) != 1 {
 		return fmt.Errorf("accepts 1 arg(s) for instance name, received %v", len(args))
 	}
 
-	if _, err := o.updateMilvusInstance(*client, context.TODO(), args[0]); err != nil {
+	ctx := context.TODO()
+	live, proposed, err := o.proposeMilvusUpdate(*c, ctx, args[0])
+	if err != nil {
 		return err
 	}
-
-	return nil
-}
-
-func (o *MilvusUpdateOptions) updateMilvusInstance(client client.Client, ctx context.Context, instanceName string) (*v1beta1.Milvus, error) {
-	// log.Printf("Creating the milvus in default namespace")
-	// var err error
-	milvus := &v1beta1.Milvus{}
-	namespacedName := types.NamespacedName{
-		Name:      instanceName,
-		Namespace: o.Namespace,
+	changed, err := o.printSpecDiff(live, proposed)
+	if err != nil {
+		return err
 	}
-	if errors.IsNotFound(client.Get(ctx, namespacedName, milvus)) {
-		return nil, fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", instanceName, o.Namespace)
+	if o.DiffOnly {
+		if changed {
+			return cmdutil.ErrExit
+		}
+		return nil
 	}
 
-	err := parsingNestedStructure(reflect.ValueOf(&milvus.Spec).Elem(), o.ResouceSetting)
-
-	if err != nil {
-		return nil, err
+	operation := "unchanged"
+	var baseline *milvus.Baseline
+	if changed {
+		if err = o.checkProtection(live, proposed); err != nil {
+			return err
+		}
+		if err = o.confirmUpdate(args[0]); err != nil {
+			return err
+		}
+		if *o.ApplyOptions.DeleteFlags.Wait {
+			// the status is still Healthy right after the update, the baseline tells when the operator reconciled it
+			if baseline, err = milvus.NewBaseline(ctx, *c, o.Namespace, args[0]); err != nil {
+				return err
+			}
+		}
+		if err = o.updateMilvusInstance(*c, ctx, proposed); err != nil {
+			return err
+		}
+		operation = "configured"
 	}
-
-	// fmt.Println("Dest spec: ", milvus.Spec)
-
-	err = client.Update(ctx, milvus)
+	printer, err := o.ApplyOptions.ToPrinter(operation)
 	if err != nil {
-		return nil, err
-	} else {
-		fmt.Printf("milvus.milvus.io/%s Update \n", instanceName)
+		return err
+	}
+	// the printers need the type meta which the typed client does not keep
+	proposed.SetGroupVersionKind(v1beta1.GroupVersion.WithKind("Milvus"))
+	if err = printer.PrintObj(proposed, o.ApplyOptions.Out); err != nil {
+		return err
 	}
 
-	return milvus, nil
+	if *o.ApplyOptions.DeleteFlags.Wait {
+		waiter := &milvus.Waiter{
+			Client:    *c,
+			Namespace: o.Namespace,
+			Name:      args[0],
+			Timeout:   *o.ApplyOptions.DeleteFlags.Timeout,
+			Out:       o.ApplyOptions.Out,
+			Baseline:  baseline,
+		}
+		return waiter.WaitForHealthy(context.TODO())
+	}
+	return nil
 }
 
-func parsingNestedStructure(v reflect.Value, values map[string]interface{}) error {
-	var err error
-
-	if values == nil {
+// runApply applies the files, the milvus instances of the files go through the same protection check
+// as --set, with --wait it then waits for them to become Healthy
+func (o *MilvusUpdateOptions) runApply(c client.Client) error {
+	ctx := context.TODO()
+	instances, err := o.fileMilvuses()
+	if err != nil {
 		return err
 	}
+	waiters := []*milvus.Waiter{}
+	for _, instance := range instances {
+		if err := o.checkFileProtection(ctx, c, instance); err != nil {
+			return err
+		}
+		if !*o.ApplyOptions.DeleteFlags.Wait {
+			continue
+		}
+		baseline, err := milvus.NewBaseline(ctx, c, instance.GetNamespace(), instance.GetName())
+		if err != nil {
+			return err
+		}
+		waiters = append(waiters, &milvus.Waiter{
+			Client:    c,
+			Namespace: instance.GetNamespace(),
+			Name:      instance.GetName(),
+			Timeout:   *o.ApplyOptions.DeleteFlags.Timeout,
+			Out:       o.ApplyOptions.Out,
+			Baseline:  baseline,
+		})
+	}
 
-	if v.Type().String() == "v1beta1.Values" {
-		srcValue := v.FieldByName("Data").Interface().(map[string]interface{})
-		newMapValue := mapOverwirte(srcValue, values)
-		v.FieldByName("Data").Set(reflect.ValueOf(newMapValue).Convert(v.FieldByName("Data").Type()))
+	if err := o.ApplyOptions.Run(); err != nil {
 		return err
 	}
-
-	tagMap := getTagMap(v.Type())
-
-	for key, value := range values {
-		field, ok := tagMap[key]
-		if ok {
-			subSpec := v.FieldByName(field)
-			setValue(subSpec, value)
-		} else if _, ok := tagMap[",inline"]; ok {
-			if tagMap[",inline"] == "ComponentSpec" {
-				if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ComponentSpec{}), key); ok {
-					subSpec := v.FieldByName("ComponentSpec").FieldByName(field)
-					setValue(subSpec, value)
-				} else {
-					err = fmt.Errorf("The field %q does not exists", key)
-				}
-			} else if tagMap[",inline"] == "Component" {
-				if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.Component{}), key); ok {
-					subSpec := v.FieldByName("Component").FieldByName(field)
-					setValue(subSpec, value)
-				} else if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ComponentSpec{}), key); ok {
-					subSpec := v.FieldByName("Component").FieldByName("ComponentSpec").FieldByName(field)
-					setValue(subSpec, value)
-				} else {
-					err = fmt.Errorf("The field %q does not exists", key)
-				}
-			} else if tagMap[",inline"] == "ServiceComponent" {
-				if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ServiceComponent{}), key); ok {
-					subSpec := v.FieldByName("ServiceComponent").FieldByName(field)
-					setValue(subSpec, value)
-				} else if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.Component{}), key); ok {
-					subSpec := v.FieldByName("ServiceComponent").FieldByName("Component").FieldByName(field)
-					setValue(subSpec, value)
-				} else if field, ok := ifTagInSpec(reflect.TypeOf(v1beta1.ComponentSpec{}), key); ok {
-					subSpec := v.FieldByName("ServiceComponent").FieldByName("Component").FieldByName("ComponentSpec").FieldByName(field)
-					setValue(subSpec, value)
-				} else {
-					err = fmt.Errorf("The field %q does not exists", key)
-				}
-			}
-		} else {
-			err = fmt.Errorf("The field %q does not exists", key)
+	for _, waiter := range waiters {
+		if err := waiter.WaitForHealthy(ctx); err != nil {
+			return err
 		}
 	}
-	return err
+	return nil
 }
 
-// Overwrite the Milvus Spev with the setting values
-func setValue(subSpec reflect.Value, value interface{}) error {
-	var err error
-	if subSpec.Kind() == reflect.Struct {
-		if istable(value) {
-			parsingNestedStructure(subSpec, value.(map[string]interface{}))
-		} else {
-			err = fmt.Errorf("Can not be overwritten with value %q", value)
+// fileMilvuses returns the milvus objects of the files, in the namespace they are applied to
+func (o *MilvusUpdateOptions) fileMilvuses() ([]*unstructured.Unstructured, error) {
+	infos, err := o.ApplyOptions.GetObjects()
+	if err != nil {
+		return nil, err
+	}
+	instances := []*unstructured.Unstructured{}
+	for _, info := range infos {
+		if info.Object.GetObjectKind().GroupVersionKind().GroupKind() != milvusGroupKind {
+			continue
 		}
-	} else if subSpec.Kind() == reflect.Ptr {
-		if subSpec.IsNil() {
-			newSpec := reflect.New(subSpec.Type().Elem())
-			if newSpec.Type().Elem().Kind() == reflect.Struct {
-				if istable(value) {
-					parsingNestedStructure(newSpec.Elem(), value.(map[string]interface{}))
-				} else {
-					err = fmt.Errorf("Can not be overwritten with value %q", value)
-				}
-			} else {
-				setValue(newSpec.Elem(), value)
-			}
-			subSpec.Set(newSpec)
-		} else {
-			if subSpec.Type().Elem().Kind() == reflect.Struct {
-				if istable(value) {
-					parsingNestedStructure(subSpec.Elem(), value.(map[string]interface{}))
-				} else {
-					err = fmt.Errorf("Can not be overwritten with value %q", value)
-				}
-			} else {
-				setValue(subSpec.Elem(), value)
-			}
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
 		}
-	} else {
-		subSpec.Set(reflect.ValueOf(value).Convert(subSpec.Type()))
+		instance := u.DeepCopy()
+		instance.SetNamespace(info.Namespace)
+		instances = append(instances, instance)
 	}
-	return err
+	return instances, nil
 }
 
-func mapOverwirte(src, values map[string]interface{}) map[string]interface{} {
-	if values == nil {
-		return src
-	}
-	for key, value := range values {
-		if sv, ok := src[key]; ok && sv != nil {
-			if istable(value) && istable(sv) {
-				mapOverwirte(sv.(map[string]interface{}), value.(map[string]interface{}))
-			} else if !istable(value) && !istable(sv) {
-				src[key] = value
-			} else {
-				fmt.Println("can't overwritter")
-				continue
-			}
-		} else {
-			src[key] = value
+// checkFileProtection runs the protection check of --set on a milvus instance of the files when it exists,
+// the update is confirmed when it recreates the storage of a protected instance with --force-unprotected
+func (o *MilvusUpdateOptions) checkFileProtection(ctx context.Context, c client.Client, instance *unstructured.Unstructured) error {
+	live := &v1beta1.Milvus{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(instance), live); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
 		}
+		return err
 	}
-	return src
+	if milvus.GetProtection(live) == nil {
+		return nil
+	}
+	proposed, err := mergeFileSpec(live, instance)
+	if err != nil {
+		return err
+	}
+	if len(milvus.StorageChanges(&live.Spec, &proposed.Spec)) == 0 {
+		return nil
+	}
+	if err := o.checkProtection(live, proposed); err != nil {
+		return err
+	}
+	return o.confirmUpdate(live.Name)
 }
 
-func ifTagInSpec(t reflect.Type, key string) (string, bool) {
-	tagMap := getTagMap(t)
-	if field, ok := tagMap[key]; ok {
-		return field, true
-	} else {
-		return "", false
+// mergeFileSpec returns a copy of the live instance with the spec of the file merged into its spec like apply
+// merges it, the fields that are not in the file keep their live value
+func mergeFileSpec(live *v1beta1.Milvus, file *unstructured.Unstructured) (*v1beta1.Milvus, error) {
+	fileSpec, _, err := unstructured.NestedMap(file.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the spec of milvus %s: %v", file.GetName(), err)
+	}
+	data, err := json.Marshal(live.Spec)
+	if err != nil {
+		return nil, err
 	}
+	liveSpec := map[string]interface{}{}
+	if err := json.Unmarshal(data, &liveSpec); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(deploy.MergeValues(liveSpec, fileSpec)); err != nil {
+		return nil, err
+	}
+	proposed := live.DeepCopy()
+	proposed.Spec = v1beta1.MilvusSpec{}
+	if err := json.Unmarshal(data, &proposed.Spec); err != nil {
+		return nil, fmt.Errorf("failed to read the spec of milvus %s: %v", file.GetName(), err)
+	}
+	return proposed, nil
+}
+
+// proposeMilvusUpdate returns the live milvus instance and a copy with the --set values applied
+func (o *MilvusUpdateOptions) proposeMilvusUpdate(c client.Client, ctx context.Context, instanceName string) (*v1beta1.Milvus, *v1beta1.Milvus, error) {
+	live := &v1beta1.Milvus{}
+	namespacedName := types.NamespacedName{
+		Name:      instanceName,
+		Namespace: o.Namespace,
+	}
+	if err := c.Get(ctx, namespacedName, live); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("milvuses.milvus.io %s do not exists in namespace: %s", instanceName, o.Namespace)
+		}
+		return nil, nil, err
+	}
+
+	milvus := live.DeepCopy()
+	if err := override.Apply(&milvus.Spec, o.ResouceSetting); err != nil {
+		return nil, nil, err
+	}
+	return live, milvus, nil
 }
 
-func getTagMap(t reflect.Type) map[string]string {
-	tagMap := make(map[string]string)
-	var tag string
-	for i := 0; i < t.NumField(); i++ {
-		tag = readTag(t.Field(i).Tag.Get("json"))
-		tagMap[tag] = t.Field(i).Name
+// checkProtection refuses changes recreating the storage of a protected instance unless --force-unprotected is set
+func (o *MilvusUpdateOptions) checkProtection(live, proposed *v1beta1.Milvus) error {
+	protection := milvus.GetProtection(live)
+	if protection == nil {
+		return nil
+	}
+	changes := milvus.StorageChanges(&live.Spec, &proposed.Spec)
+	if len(changes) == 0 {
+		return nil
+	}
+	if o.ForceUnprotected {
+		fmt.Fprintf(o.ApplyOptions.ErrOut, "Warning: recreating the storage of milvus %s which is %s\n", live.Name, protection)
+		return nil
 	}
-	return tagMap
+	return fmt.Errorf("milvus %s is %s, the update would recreate its storage:\n  %s\nrun 'milvusctl unprotect %s' or pass --force-unprotected to update anyway",
+		live.Name, protection, strings.Join(changes, "\n  "), live.Name)
 }
 
-// istable is a special-purpose function to see if the present thing matches the definition of a YAML table.
-func istable(v interface{}) bool {
-	_, ok := v.(map[string]interface{})
-	return ok
+// confirmUpdate asks before applying the change unless --yes or --dry-run is set
+func (o *MilvusUpdateOptions) confirmUpdate(instanceName string) error {
+	if o.Yes || o.ApplyOptions.DryRunStrategy != cmdutil.DryRunNone {
+		return nil
+	}
+	fmt.Fprintf(o.ApplyOptions.ErrOut, "apply the changes to milvus %s? [y/N] ", instanceName)
+	answer, _ := bufio.NewReader(o.ApplyOptions.In).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("update of milvus %s aborted, pass --yes to apply without confirmation", instanceName)
 }
 
-func readTag(tag string) string {
-	if tag != "" {
-		res := strings.Split(tag, ",")
-		if res[0] != "" {
-			return res[0]
-		}
+func (o *MilvusUpdateOptions) updateMilvusInstance(c client.Client, ctx context.Context, milvus *v1beta1.Milvus) error {
+	switch o.ApplyOptions.DryRunStrategy {
+	case cmdutil.DryRunClient:
+		return nil
+	case cmdutil.DryRunServer:
+		return c.Update(ctx, milvus, client.DryRunAll)
 	}
-	return tag
+	return c.Update(ctx, milvus)
 }
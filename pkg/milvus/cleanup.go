@@ -0,0 +1,206 @@
+package milvus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// WorkloadKinds are torn down by the operator after the milvus instance is deleted
+	WorkloadKinds = []string{"deployment", "statefulset", "job", "pod"}
+	// OrphanKinds may be left behind once the milvus instance and its workloads are gone
+	OrphanKinds = []string{"persistentvolumeclaim", "service", "secret"}
+)
+
+// Leftover is an object that still carries the labels of a milvus instance
+type Leftover struct {
+	Kind        string
+	Name        string
+	Terminating bool
+}
+
+func (l Leftover) String() string {
+	return l.Kind + "/" + l.Name
+}
+
+// InstanceSelectors returns the selectors of the milvus components and of every in-cluster dependence of the instance
+func InstanceSelectors(instanceName string) []string {
+	selectors := []string{InstanceSelector(instanceName)}
+	for _, dependence := range Dependences {
+		selector, _ := DependenceSelector(instanceName, dependence)
+		selectors = append(selectors, selector)
+	}
+	return selectors
+}
+
+// ListLeftovers lists the objects of the kinds that match one of the selectors of the instance
+func ListLeftovers(ctx context.Context, c client.Client, namespace string, instanceName string, kinds []string) ([]Leftover, error) {
+	leftovers := []Leftover{}
+	for _, kind := range kinds {
+		objects, err := ListObjects(ctx, c, namespace, instanceName, kind)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			leftovers = append(leftovers, Leftover{
+				Kind:        kind,
+				Name:        object.GetName(),
+				Terminating: object.GetDeletionTimestamp() != nil,
+			})
+		}
+	}
+	return leftovers, nil
+}
+
+// ListObjects lists the objects of the kind that match one of the selectors of the instance
+func ListObjects(ctx context.Context, c client.Client, namespace string, instanceName string, kind string) ([]client.Object, error) {
+	result := []client.Object{}
+	seen := map[string]bool{}
+	for _, selector := range InstanceSelectors(instanceName) {
+		list, err := newList(kind)
+		if err != nil {
+			return nil, err
+		}
+		if err := ListBySelector(ctx, c, namespace, selector, list); err != nil {
+			return nil, err
+		}
+		objects, err := apimeta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			o, ok := object.(client.Object)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object %T", object)
+			}
+			if seen[o.GetName()] {
+				continue
+			}
+			seen[o.GetName()] = true
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+func newList(kind string) (client.ObjectList, error) {
+	switch kind {
+	case "deployment":
+		return &appsv1.DeploymentList{}, nil
+	case "statefulset":
+		return &appsv1.StatefulSetList{}, nil
+	case "job":
+		return &batchv1.JobList{}, nil
+	case "pod":
+		return &corev1.PodList{}, nil
+	case "persistentvolumeclaim":
+		return &corev1.PersistentVolumeClaimList{}, nil
+	case "service":
+		return &corev1.ServiceList{}, nil
+	case "secret":
+		return &corev1.SecretList{}, nil
+	case "configmap":
+		return &corev1.ConfigMapList{}, nil
+	}
+	return nil, fmt.Errorf("unsupported kind %s", kind)
+}
+
+// WaitForDeletion blocks until the milvus instance and its workloads are gone, printing what is
+// still terminating, then reports the claims, services and secrets left behind
+func (w *Waiter) WaitForDeletion(ctx context.Context) error {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+
+	start := time.Now()
+	lastProgress := ""
+	key := client.ObjectKey{Namespace: w.Namespace, Name: w.Name}
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		remaining := []Leftover{}
+		m := &v1beta1.Milvus{}
+		if err := w.Client.Get(ctx, key, m); err == nil {
+			remaining = append(remaining, Leftover{Kind: "milvus", Name: m.Name, Terminating: m.DeletionTimestamp != nil})
+		} else if !errors.IsNotFound(err) {
+			// keep polling through transient api errors
+			return false, nil
+		}
+		workloads, err := ListLeftovers(ctx, w.Client, w.Namespace, w.Name, WorkloadKinds)
+		if err != nil {
+			return false, nil
+		}
+		remaining = append(remaining, workloads...)
+		if len(remaining) == 0 {
+			return true, nil
+		}
+
+		progress := formatLeftovers(remaining)
+		if progress != lastProgress {
+			fmt.Fprintf(w.Out, "[%s] Terminating: %s\n", time.Since(start).Round(time.Second), progress)
+			lastProgress = progress
+		}
+		return false, nil
+	})
+	if err != nil && err != wait.ErrWaitTimeout {
+		return err
+	}
+
+	if err == nil {
+		fmt.Fprintf(w.Out, "milvus.milvus.io/%s and its workloads are deleted\n", w.Name)
+	}
+	if reportErr := w.reportOrphans(ctx); reportErr != nil {
+		return reportErr
+	}
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out after %s waiting for milvus %s to be deleted", timeout, w.Name)
+	}
+	return nil
+}
+
+func (w *Waiter) reportOrphans(ctx context.Context) error {
+	orphans, err := ListLeftovers(ctx, w.Client, w.Namespace, w.Name, OrphanKinds)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w.Out, "the following resources still carry the labels of milvus %s:\n", w.Name)
+	for _, orphan := range orphans {
+		fmt.Fprintf(w.Out, "  %s\n", orphan)
+	}
+	return nil
+}
+
+// formatLeftovers lists at most ten objects, the objects that are not marked for deletion yet are flagged
+func formatLeftovers(leftovers []Leftover) string {
+	const max = 10
+	parts := []string{}
+	for i, leftover := range leftovers {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("and %d more", len(leftovers)-max))
+			break
+		}
+		if leftover.Terminating {
+			parts = append(parts, leftover.String())
+		} else {
+			parts = append(parts, leftover.String()+" (pending)")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
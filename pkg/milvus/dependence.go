@@ -0,0 +1,55 @@
+package milvus
+
+import (
+	"context"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InClusterDependence returns the in-cluster config of a dependence of the spec,
+// it is nil when the dependence is external or not deployed by the operator
+func InClusterDependence(spec *v1beta1.MilvusSpec, dependence string) *v1beta1.InClusterConfig {
+	switch dependence {
+	case "etcd":
+		if spec.Dep.Etcd.External {
+			return nil
+		}
+		return spec.Dep.Etcd.InCluster
+	case "minio":
+		if spec.Dep.Storage.External {
+			return nil
+		}
+		return spec.Dep.Storage.InCluster
+	case "pulsar":
+		if spec.Dep.Pulsar.External {
+			return nil
+		}
+		return spec.Dep.Pulsar.InCluster
+	case "kafka":
+		if spec.Dep.Kafka.External {
+			return nil
+		}
+		return spec.Dep.Kafka.InCluster
+	}
+	return nil
+}
+
+// DeletesPVC reports if the operator deletes the volumes of the in-cluster dependence with the instance
+func DeletesPVC(config *v1beta1.InClusterConfig) bool {
+	return config != nil && config.PVCDeletion && config.DeletionPolicy == v1beta1.DeletionPolicyDelete
+}
+
+// ListPVCs returns the persistent volume claims of an in-cluster dependence of the instance
+func ListPVCs(ctx context.Context, c client.Client, namespace string, instanceName string, dependence string) ([]corev1.PersistentVolumeClaim, error) {
+	selector, err := DependenceSelector(instanceName, dependence)
+	if err != nil {
+		return nil, err
+	}
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := ListBySelector(ctx, c, namespace, selector, pvcs); err != nil {
+		return nil, err
+	}
+	return pvcs.Items, nil
+}
@@ -0,0 +1,163 @@
+package milvus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Verdict is the overall health of a milvus instance
+type Verdict string
+
+const (
+	VerdictHealthy  Verdict = "HEALTHY"
+	VerdictDegraded Verdict = "DEGRADED"
+	VerdictDown     Verdict = "DOWN"
+
+	// DefaultRestartWindow is the window of the restarts counted by the health check
+	DefaultRestartWindow = time.Hour
+)
+
+// WorkloadHealth is the readiness and the recent restarts of a component or a dependence
+type WorkloadHealth struct {
+	WorkloadStatus
+	// Restarts counts the restarts of the containers that last restarted within the window,
+	// the kubelet only keeps the last termination of a container so older restarts of such
+	// a container are counted as well
+	Restarts int32
+	// Pods are the pods of the workload
+	Pods []corev1.Pod
+}
+
+// Health is the health summary of a milvus instance
+type Health struct {
+	Milvus            *v1beta1.Milvus
+	Workloads         []WorkloadHealth
+	FailingConditions []v1beta1.MilvusCondition
+	Verdict           Verdict
+	// Reasons explain why the instance is not healthy
+	Reasons []string
+}
+
+// CheckHealth gets the milvus instance, its workloads and their pods and judges its health
+func CheckHealth(ctx context.Context, c client.Client, namespace string, instanceName string, restartWindow time.Duration) (*Health, error) {
+	m := &v1beta1.Milvus{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: instanceName}, m); err != nil {
+		return nil, err
+	}
+	workloads, err := ListWorkloads(ctx, c, namespace, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	pods := []corev1.Pod{}
+	for _, selector := range InstanceSelectors(instanceName) {
+		list := &corev1.PodList{}
+		if err := ListBySelector(ctx, c, namespace, selector, list); err != nil {
+			return nil, err
+		}
+		pods = append(pods, list.Items...)
+	}
+	return Judge(m, workloads, pods, restartWindow, time.Now()), nil
+}
+
+// Judge matches the pods with their workloads and judges the health of the instance:
+// it is DOWN when the instance is not healthy and a component or dependence has no ready
+// replica, DEGRADED when anything is not ready, failing or restarting, HEALTHY otherwise
+func Judge(m *v1beta1.Milvus, workloads []WorkloadStatus, pods []corev1.Pod, restartWindow time.Duration, now time.Time) *Health {
+	health := &Health{Milvus: m, Verdict: VerdictHealthy}
+	for _, workload := range workloads {
+		workloadHealth := WorkloadHealth{WorkloadStatus: workload}
+		if selector, err := metav1.LabelSelectorAsSelector(workload.Selector); err == nil && workload.Selector != nil {
+			for _, pod := range pods {
+				if selector.Matches(labels.Set(pod.Labels)) {
+					workloadHealth.Pods = append(workloadHealth.Pods, pod)
+					workloadHealth.Restarts += RecentRestarts(&pod, now.Add(-restartWindow))
+				}
+			}
+		}
+		health.Workloads = append(health.Workloads, workloadHealth)
+	}
+	for _, condition := range m.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			health.FailingConditions = append(health.FailingConditions, condition)
+		}
+	}
+
+	down := []string{}
+	degraded := []string{}
+	if len(health.Workloads) == 0 {
+		down = append(down, "no workload found")
+	}
+	for _, workload := range health.Workloads {
+		switch {
+		case workload.Desired > 0 && workload.Ready == 0:
+			down = append(down, fmt.Sprintf("%s has no ready replica", workload.Name))
+		case !workload.IsReady():
+			degraded = append(degraded, fmt.Sprintf("%s %d/%d ready", workload.Name, workload.Ready, workload.Desired))
+		}
+		if workload.Restarts > 0 {
+			degraded = append(degraded, fmt.Sprintf("%s restarted %d times in the last %s", workload.Name, workload.Restarts, ShortDuration(restartWindow)))
+		}
+	}
+	for _, condition := range health.FailingConditions {
+		degraded = append(degraded, fmt.Sprintf("%s is %s", condition.Type, condition.Status))
+	}
+	if m.Status.Status != v1beta1.StatusHealthy {
+		status := string(m.Status.Status)
+		if status == "" {
+			status = "Pending"
+		}
+		degraded = append([]string{"status is " + status}, degraded...)
+	}
+
+	switch {
+	case len(down) != 0 && m.Status.Status != v1beta1.StatusHealthy:
+		health.Verdict = VerdictDown
+		health.Reasons = append(down, degraded...)
+	case len(down) != 0 || len(degraded) != 0:
+		health.Verdict = VerdictDegraded
+		health.Reasons = append(down, degraded...)
+	}
+	return health
+}
+
+// RecentRestarts returns the restarts of the containers of the pod whose last termination is after since
+func RecentRestarts(pod *corev1.Pod, since time.Time) int32 {
+	restarts := int32(0)
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		terminated := status.LastTerminationState.Terminated
+		if status.RestartCount > 0 && terminated != nil && terminated.FinishedAt.Time.After(since) {
+			restarts += status.RestartCount
+		}
+	}
+	return restarts
+}
+
+// ExitCode is the exit code of milvusctl status for the verdict
+func (v Verdict) ExitCode() int {
+	switch v {
+	case VerdictHealthy:
+		return 0
+	case VerdictDegraded:
+		return 1
+	}
+	return 2
+}
+
+// ShortDuration formats whole hours and minutes like 1h or 10m
+func ShortDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
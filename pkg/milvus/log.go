@@ -0,0 +1,134 @@
+package milvus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogTimeLayout is the layout of the timestamp of the milvus logs
+const LogTimeLayout = "2006/01/02 15:04:05.000 -07:00"
+
+// LogLevels are the levels of the milvus logs, from the least to the most severe
+var LogLevels = []string{"DEBUG", "INFO", "WARN", "ERROR", "DPANIC", "PANIC", "FATAL"}
+
+// LogLine is a parsed line of the `[timestamp] [LEVEL] [file:line] ["message"] [key=value]...` milvus log format
+type LogLine struct {
+	Time    time.Time
+	Level   string
+	Caller  string
+	Message string
+	// Fields are the structured key/value pairs following the message
+	Fields map[string]string
+}
+
+// ParseLogLevel returns the severity of the level, its index in LogLevels, or an error when it is unknown
+func ParseLogLevel(level string) (int, error) {
+	level = strings.ToUpper(level)
+	if level == "WARNING" {
+		level = "WARN"
+	}
+	for i, l := range LogLevels {
+		if l == level {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown log level: %s. choose one of them: %s", level, strings.ToLower(strings.Join(LogLevels, ", ")))
+}
+
+// ParseLogLine parses a line of the milvus log format, it returns false when the line is in another format,
+// like the lines of a stack trace or of the dependences
+func ParseLogLine(line string) (*LogLine, bool) {
+	groups, rest := logGroups(strings.TrimRight(line, "\r\n"))
+	if len(groups) < 2 || strings.TrimSpace(rest) != "" {
+		return nil, false
+	}
+	t, err := time.Parse(LogTimeLayout, groups[0])
+	if err != nil {
+		return nil, false
+	}
+	if _, err := ParseLogLevel(groups[1]); err != nil {
+		return nil, false
+	}
+
+	l := &LogLine{Time: t, Level: groups[1]}
+	groups = groups[2:]
+	if len(groups) != 0 {
+		l.Caller = groups[0]
+		groups = groups[1:]
+	}
+	if len(groups) != 0 {
+		l.Message = unquote(groups[0])
+		groups = groups[1:]
+	}
+	for _, group := range groups {
+		key, value, ok := splitField(group)
+		if !ok {
+			// not a field, it is kept with the message
+			l.Message += " " + group
+			continue
+		}
+		if l.Fields == nil {
+			l.Fields = map[string]string{}
+		}
+		l.Fields[key] = unquote(value)
+	}
+	return l, true
+}
+
+// logGroups splits the leading `[...]` groups of the line, the brackets inside the quoted values
+// and the nested brackets of the unquoted values belong to their group. The text after the last
+// group is returned as rest
+func logGroups(line string) ([]string, string) {
+	groups := []string{}
+	i := 0
+	for {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i >= len(line) || line[i] != '[' {
+			return groups, line[i:]
+		}
+		start, depth, quoted := i+1, 0, false
+		end := -1
+		for j := start; j < len(line) && end < 0; j++ {
+			switch c := line[j]; {
+			case quoted && c == '\\':
+				j++
+			case c == '"':
+				quoted = !quoted
+			case quoted:
+			case c == '[':
+				depth++
+			case c == ']' && depth > 0:
+				depth--
+			case c == ']':
+				end = j
+			}
+		}
+		if end < 0 {
+			return groups, line[i:]
+		}
+		groups = append(groups, line[start:end])
+		i = end + 1
+	}
+}
+
+// splitField splits a key=value group, the key is never quoted
+func splitField(group string) (string, string, bool) {
+	i := strings.Index(group, "=")
+	if i <= 0 || strings.ContainsAny(group[:i], "\" []") {
+		return "", "", false
+	}
+	return group[:i], group[i+1:], true
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package milvus
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	logTime := time.Date(2022, 8, 1, 8, 39, 2, 123000000, time.UTC)
+	tests := []struct {
+		name string
+		line string
+		// want is nil when the line is not in the milvus format
+		want *LogLine
+	}{
+		{
+			name: "message only",
+			line: `[2022/08/01 08:39:02.123 +00:00] [INFO] [rootcoord/root_coord.go:1085] ["RootCoord start successfully "]`,
+			want: &LogLine{Level: "INFO", Caller: "rootcoord/root_coord.go:1085", Message: "RootCoord start successfully "},
+		},
+		{
+			name: "quoted message with brackets",
+			line: `[2022/08/01 08:39:02.123 +00:00] [WARN] [proxy/impl.go:2437] ["[Search] failed to get shard leaders"] [collection=book]`,
+			want: &LogLine{Level: "WARN", Caller: "proxy/impl.go:2437", Message: "[Search] failed to get shard leaders", Fields: map[string]string{"collection": "book"}},
+		},
+		{
+			name: "unquoted message with nested brackets",
+			line: `[2022/08/01 08:39:02.123 +00:00] [DEBUG] [sessionutil/session_util.go:291] [Session [proxy-1] expired]`,
+			want: &LogLine{Level: "DEBUG", Caller: "sessionutil/session_util.go:291", Message: "Session [proxy-1] expired"},
+		},
+		{
+			name: "quoted fields with spaces and brackets",
+			line: `[2022/08/01 08:39:02.123 +00:00] [ERROR] [querynode/segment_loader.go:316] ["failed to load segment"] [segmentID=434] [error="segment [434] not found: \"sealed\""] [dsl="book_intro > 1"] [db=]`,
+			want: &LogLine{
+				Level:   "ERROR",
+				Caller:  "querynode/segment_loader.go:316",
+				Message: "failed to load segment",
+				Fields:  map[string]string{"segmentID": "434", "error": `segment [434] not found: "sealed"`, "dsl": "book_intro > 1", "db": ""},
+			},
+		},
+		{
+			name: "group that is not a field",
+			line: `[2022/08/01 08:39:02.123 +00:00] [INFO] [datacoord/server.go:300] ["flush segments"] [done] [count=2]`,
+			want: &LogLine{Level: "INFO", Caller: "datacoord/server.go:300", Message: "flush segments done", Fields: map[string]string{"count": "2"}},
+		},
+		{
+			name: "lowercase warning level",
+			line: `[2022/08/01 08:39:02.123 +00:00] [warning] [proxy/task.go:120] ["slow task"]` + "\r\n",
+			want: &LogLine{Level: "warning", Caller: "proxy/task.go:120", Message: "slow task"},
+		},
+		{
+			name: "text after the groups",
+			line: `[2022/08/01 08:39:02.123 +00:00] [INFO] [proxy/task.go:120] ["slow task"] took 3s`,
+		},
+		{
+			name: "unknown level",
+			line: `[2022/08/01 08:39:02.123 +00:00] [TRACE] [proxy/task.go:120] ["slow task"]`,
+		},
+		{
+			name: "other timestamp layout",
+			line: `[2022-08-01T08:39:02.123Z] [INFO] [proxy/task.go:120] ["slow task"]`,
+		},
+		{
+			name: "pulsar",
+			line: `2022-08-01T08:39:02,123+0000 [main] INFO  org.apache.pulsar.broker.PulsarService - messaging service is ready`,
+		},
+		{
+			name: "etcd",
+			line: `{"level":"info","ts":"2022-08-01T08:39:02.123Z","caller":"etcdserver/server.go:2042","msg":"published local member"}`,
+		},
+		{
+			name: "stack trace",
+			line: `goroutine 1 [running]:`,
+		},
+		{
+			name: "unterminated group",
+			line: `[2022/08/01 08:39:02.123 +00:00] [INFO] [proxy/task.go:120] ["slow task`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseLogLine(tt.line)
+			if tt.want == nil {
+				if ok {
+					t.Errorf("ParseLogLine(%q) = %+v, want no milvus line", tt.line, got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("ParseLogLine(%q) failed", tt.line)
+			}
+			want := *tt.want
+			want.Time = logTime
+			if !got.Time.Equal(want.Time) {
+				t.Errorf("time = %s, want %s", got.Time, want.Time)
+			}
+			got.Time = want.Time
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("got %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  int
+	}{
+		{level: "debug", want: 0},
+		{level: "INFO", want: 1},
+		{level: "WARN", want: 2},
+		{level: "warn", want: 2},
+		{level: "WARNING", want: 2},
+		{level: "Warning", want: 2},
+		{level: "error", want: 3},
+		{level: "FATAL", want: 6},
+		{level: "trace", want: -1},
+		{level: "", want: -1},
+	}
+	for _, tt := range tests {
+		got, err := ParseLogLevel(tt.level)
+		if got != tt.want || (err != nil) != (tt.want < 0) {
+			t.Errorf("ParseLogLevel(%q) = %d, %v, want %d", tt.level, got, err, tt.want)
+		}
+	}
+}
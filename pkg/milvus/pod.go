@@ -0,0 +1,59 @@
+package milvus
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodReason summarizes the pod like the STATUS column of kubectl get pods, like CrashLoopBackOff,
+// Init:Error or Init:0/1
+func PodReason(pod *corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	initStatuses := containerStatusesByName(pod.Status.InitContainerStatuses)
+	for i, container := range pod.Spec.InitContainers {
+		state := initStatuses[container.Name].State
+		switch {
+		case state.Terminated != nil && state.Terminated.ExitCode == 0:
+			continue
+		case state.Terminated != nil:
+			if state.Terminated.Reason != "" {
+				return "Init:" + state.Terminated.Reason
+			}
+			return fmt.Sprintf("Init:ExitCode:%d", state.Terminated.ExitCode)
+		case state.Waiting != nil && state.Waiting.Reason != "" && state.Waiting.Reason != "PodInitializing":
+			return "Init:" + state.Waiting.Reason
+		default:
+			return fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+		}
+	}
+
+	reason := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		reason = pod.Status.Reason
+	}
+	statuses := containerStatusesByName(pod.Status.ContainerStatuses)
+	for _, container := range pod.Spec.Containers {
+		state := statuses[container.Name].State
+		switch {
+		case state.Waiting != nil && state.Waiting.Reason != "":
+			return state.Waiting.Reason
+		case state.Terminated != nil && state.Terminated.Reason != "":
+			return state.Terminated.Reason
+		}
+	}
+	if reason == "" {
+		return string(corev1.PodPending)
+	}
+	return reason
+}
+
+func containerStatusesByName(statuses []corev1.ContainerStatus) map[string]corev1.ContainerStatus {
+	byName := map[string]corev1.ContainerStatus{}
+	for _, status := range statuses {
+		byName[status.Name] = status
+	}
+	return byName
+}
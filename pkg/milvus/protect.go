@@ -0,0 +1,129 @@
+package milvus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+)
+
+const (
+	// ProtectedByAnnotation records who protected the milvus instance against deletion
+	ProtectedByAnnotation = "milvusctl.milvus.io/protected-by"
+	// ProtectedAtAnnotation records when the milvus instance was protected, in RFC3339
+	ProtectedAtAnnotation = "milvusctl.milvus.io/protected-at"
+)
+
+// Protection is the deletion protection set by milvusctl protect
+type Protection struct {
+	By string
+	At string
+}
+
+func (p *Protection) String() string {
+	return fmt.Sprintf("protected by %s at %s", p.By, p.At)
+}
+
+// GetProtection returns the deletion protection of the instance, nil if it is not protected
+func GetProtection(m *v1beta1.Milvus) *Protection {
+	by, ok := m.Annotations[ProtectedByAnnotation]
+	if !ok {
+		return nil
+	}
+	return &Protection{By: by, At: m.Annotations[ProtectedAtAnnotation]}
+}
+
+// Protect sets the deletion protection annotations on the instance
+func Protect(m *v1beta1.Milvus, by string, at time.Time) {
+	if m.Annotations == nil {
+		m.Annotations = map[string]string{}
+	}
+	m.Annotations[ProtectedByAnnotation] = by
+	m.Annotations[ProtectedAtAnnotation] = at.UTC().Format(time.RFC3339)
+}
+
+// Unprotect removes the deletion protection annotations from the instance
+func Unprotect(m *v1beta1.Milvus) {
+	delete(m.Annotations, ProtectedByAnnotation)
+	delete(m.Annotations, ProtectedAtAnnotation)
+}
+
+// StorageChanges lists the changes between two specs that make the operator
+// recreate the storage of the instance, and so lose its data
+func StorageChanges(live, proposed *v1beta1.MilvusSpec) []string {
+	changes := []string{}
+	if live.Dep.MsgStreamType != proposed.Dep.MsgStreamType {
+		changes = append(changes, fmt.Sprintf("dependencies.msgStreamType changes from %q to %q", live.Dep.MsgStreamType, proposed.Dep.MsgStreamType))
+	}
+	if live.Dep.Storage.Type != proposed.Dep.Storage.Type {
+		changes = append(changes, fmt.Sprintf("dependencies.storage.type changes from %q to %q", live.Dep.Storage.Type, proposed.Dep.Storage.Type))
+	}
+	if !reflect.DeepEqual(live.Dep.RocksMQ.Persistence, proposed.Dep.RocksMQ.Persistence) {
+		changes = append(changes, "dependencies.rocksmq.persistence changes")
+	}
+
+	for _, dependence := range Dependences {
+		path := "dependencies." + dependence
+		if dependence == "minio" {
+			path = "dependencies.storage"
+		}
+		before := InClusterDependence(live, dependence)
+		after := InClusterDependence(proposed, dependence)
+		switch {
+		case before == nil && after == nil:
+			continue
+		case before == nil:
+			changes = append(changes, path+" moves in cluster")
+			continue
+		case after == nil:
+			changes = append(changes, path+" moves out of cluster")
+			continue
+		}
+		changes = append(changes, persistenceChanges(before.Values.Data, after.Values.Data, path+".inCluster.values")...)
+	}
+	return changes
+}
+
+// persistenceChanges compares the persistence and volumes sections of the helm values of
+// a dependence, the volume claim templates of its statefulsets can not be updated in place
+func persistenceChanges(before, after map[string]interface{}, path string) []string {
+	changes := []string{}
+	keys := []string{}
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		keyPath := path + "." + key
+		if key == "persistence" || key == "volumes" {
+			if !sameValues(before[key], after[key]) {
+				changes = append(changes, keyPath+" changes")
+			}
+			continue
+		}
+		b, _ := before[key].(map[string]interface{})
+		a, _ := after[key].(map[string]interface{})
+		if b != nil || a != nil {
+			changes = append(changes, persistenceChanges(b, a, keyPath)...)
+		}
+	}
+	return changes
+}
+
+// sameValues compares helm values by their json, numbers are decoded either as int64 or float64
+func sameValues(a, b interface{}) bool {
+	aJson, aErr := json.Marshal(a)
+	bJson, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aJson) == string(bJson)
+}
@@ -0,0 +1,52 @@
+package milvus
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+)
+
+// SecretRefs returns the secrets referenced by the spec with the keys that are used, an empty
+// list of keys means the whole secret is used, like the secret of the storage
+func SecretRefs(spec *v1beta1.MilvusSpec) map[string][]string {
+	refs := map[string][]string{}
+	if spec.Dep.Storage.SecretRef != "" {
+		refs[spec.Dep.Storage.SecretRef] = []string{}
+	}
+
+	// the env of the components reference secrets with valueFrom.secretKeyRef
+	data, err := json.Marshal(spec.Com)
+	if err != nil {
+		return refs
+	}
+	var com interface{}
+	if err := json.Unmarshal(data, &com); err != nil {
+		return refs
+	}
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["secretKeyRef"].(map[string]interface{}); ok {
+				name, _ := ref["name"].(string)
+				key, _ := ref["key"].(string)
+				if keys, ok := refs[name]; name != "" && (!ok || len(keys) != 0) {
+					refs[name] = append(keys, key)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(com)
+	for _, keys := range refs {
+		sort.Strings(keys)
+	}
+	return refs
+}
@@ -0,0 +1,48 @@
+package milvus
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// Components are the values of the app.kubernetes.io/component label of the milvus workloads
+	Components = []string{"proxy", "rootcoord", "mixcoord", "indexcoord", "querycoord", "datacoord", "indexnode", "querynode", "datanode", "standalone"}
+	// Dependences are the in-cluster dependences the operator may deploy for a milvus instance
+	Dependences = []string{"etcd", "minio", "pulsar", "kafka"}
+)
+
+// InstanceSelector selects every milvus component workload of the instance
+func InstanceSelector(instanceName string) string {
+	return "app.kubernetes.io/name=milvus, app.kubernetes.io/instance=" + instanceName
+}
+
+// ComponentSelector selects the workloads of a single milvus component of the instance
+func ComponentSelector(instanceName string, component string) string {
+	return InstanceSelector(instanceName) + ", app.kubernetes.io/component=" + component
+}
+
+// DependenceSelector selects the workloads of an in-cluster dependence of the instance
+func DependenceSelector(instanceName string, dependence string) (string, error) {
+	switch dependence {
+	case "etcd":
+		return "app.kubernetes.io/instance=" + instanceName + "-etcd, app.kubernetes.io/name=etcd", nil
+	case "minio":
+		return "release=" + instanceName + "-minio, app=minio", nil
+	case "pulsar":
+		return "cluster=" + instanceName + "-pulsar, app=pulsar", nil
+	case "kafka":
+		return "app.kubernetes.io/instance=" + instanceName + "-kafka, app.kubernetes.io/component=kafka", nil
+	}
+	return "", fmt.Errorf("dependence parameter error: %s. choose one of them: %s", dependence, strings.Join(Dependences, ", "))
+}
+
+// IsComponent reports if the name is one of the milvus components
+func IsComponent(name string) bool {
+	for _, component := range Components {
+		if component == name {
+			return true
+		}
+	}
+	return false
+}
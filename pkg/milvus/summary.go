@@ -0,0 +1,141 @@
+package milvus
+
+import (
+	"strings"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+)
+
+// ComponentReplicas is the ready and desired replicas of a milvus component
+type ComponentReplicas struct {
+	Component string
+	Ready     int
+	Desired   int
+}
+
+// Replicas returns the replicas of the components of the instance, the ready replicas come
+// from the milvus status and the desired ones from the spec, which the operator defaults to 1
+func Replicas(m *v1beta1.Milvus) []ComponentReplicas {
+	com := m.Spec.Com
+	status := m.Status.Replicas
+	replicas := []ComponentReplicas{}
+	add := func(component string, spec *v1beta1.Component, ready int) {
+		if spec == nil && ready == 0 {
+			return
+		}
+		desired := 1
+		if spec != nil && spec.Replicas != nil {
+			desired = int(*spec.Replicas)
+		}
+		replicas = append(replicas, ComponentReplicas{Component: component, Ready: ready, Desired: desired})
+	}
+
+	if m.Spec.Mode != v1beta1.MilvusModeCluster {
+		var standalone *v1beta1.Component
+		if com.Standalone != nil {
+			standalone = &com.Standalone.Component
+		}
+		add("standalone", standalone, status.Standalone)
+		return replicas
+	}
+
+	var proxy, mixCoord, rootCoord, dataCoord, indexCoord, queryCoord, dataNode, indexNode, queryNode *v1beta1.Component
+	if com.Proxy != nil {
+		proxy = &com.Proxy.Component
+	}
+	if com.MixCoord != nil {
+		mixCoord = &com.MixCoord.Component
+	}
+	if com.RootCoord != nil {
+		rootCoord = &com.RootCoord.Component
+	}
+	if com.DataCoord != nil {
+		dataCoord = &com.DataCoord.Component
+	}
+	if com.IndexCoord != nil {
+		indexCoord = &com.IndexCoord.Component
+	}
+	if com.QueryCoord != nil {
+		queryCoord = &com.QueryCoord.Component
+	}
+	if com.DataNode != nil {
+		dataNode = &com.DataNode.Component
+	}
+	if com.IndexNode != nil {
+		indexNode = &com.IndexNode.Component
+	}
+	if com.QueryNode != nil {
+		queryNode = &com.QueryNode.Component
+	}
+	add("proxy", proxy, status.Proxy)
+	add("mixcoord", mixCoord, status.MixCoord)
+	add("rootcoord", rootCoord, status.RootCoord)
+	add("datacoord", dataCoord, status.DataCoord)
+	add("indexcoord", indexCoord, status.IndexCoord)
+	add("querycoord", queryCoord, status.QueryCoord)
+	add("datanode", dataNode, status.DataNode)
+	add("indexnode", indexNode, status.IndexNode)
+	add("querynode", queryNode, status.QueryNode)
+	return replicas
+}
+
+// Dependency is how a dependence of the instance is deployed
+type Dependency struct {
+	Name string
+	// Kind is in-cluster, external or embedded
+	Kind string
+}
+
+func (d Dependency) String() string {
+	return d.Name + ":" + d.Kind
+}
+
+// Dependencies returns the etcd, the storage and the message stream of the instance
+func Dependencies(spec *v1beta1.MilvusSpec) []Dependency {
+	kind := func(external bool) string {
+		if external {
+			return "external"
+		}
+		return "in-cluster"
+	}
+	dependencies := []Dependency{
+		{Name: "etcd", Kind: kind(spec.Dep.Etcd.External)},
+		{Name: "storage", Kind: kind(spec.Dep.Storage.External)},
+	}
+	switch MsgStream(spec) {
+	case v1beta1.MsgStreamTypeKafka:
+		dependencies = append(dependencies, Dependency{Name: "kafka", Kind: kind(spec.Dep.Kafka.External)})
+	case v1beta1.MsgStreamTypeRocksMQ:
+		dependencies = append(dependencies, Dependency{Name: "rocksmq", Kind: "embedded"})
+	default:
+		dependencies = append(dependencies, Dependency{Name: "pulsar", Kind: kind(spec.Dep.Pulsar.External)})
+	}
+	return dependencies
+}
+
+// MsgStream returns the message stream of the instance, which defaults to rocksmq for
+// a standalone instance and to pulsar for a cluster
+func MsgStream(spec *v1beta1.MilvusSpec) v1beta1.MsgStreamType {
+	if spec.Dep.MsgStreamType != "" {
+		return spec.Dep.MsgStreamType
+	}
+	if spec.Mode == v1beta1.MilvusModeCluster {
+		return v1beta1.MsgStreamTypePulsar
+	}
+	return v1beta1.MsgStreamTypeRocksMQ
+}
+
+// ImageVersion returns the tag of the milvus image, like v2.1.0
+func ImageVersion(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.Index(name, "@"); i >= 0 {
+		return name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	if image == "" {
+		return ""
+	}
+	return "latest"
+}
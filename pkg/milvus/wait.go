@@ -0,0 +1,273 @@
+package milvus
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultWaitTimeout is used when --wait is set without a --timeout
+	DefaultWaitTimeout = 10 * time.Minute
+	// DefaultWaitInterval is the interval between two polls of the instance
+	DefaultWaitInterval = 5 * time.Second
+	// DefaultReconcileGracePeriod is how long the stale Healthy status of an updated instance is not trusted
+	// when the operator changes none of its workloads
+	DefaultReconcileGracePeriod = 30 * time.Second
+)
+
+// WorkloadStatus is the readiness of a deployment or statefulset of a milvus instance
+type WorkloadStatus struct {
+	// Name is the milvus component or dependence the workload belongs to, like querynode or pulsar-bookie
+	Name     string
+	Kind     string
+	Workload string
+	// Generation is the generation of the workload spec
+	Generation int64
+	Ready      int32
+	Desired    int32
+	// RolledOut is false while the controller has not finished rolling out the latest spec
+	RolledOut bool
+	// Selector selects the pods of the workload
+	Selector *metav1.LabelSelector
+}
+
+// IsReady reports if every desired replica of the workload is ready and up to date
+func (w WorkloadStatus) IsReady() bool {
+	return w.RolledOut && w.Ready >= w.Desired
+}
+
+// ListWorkloads returns the milvus component deployments and the dependence statefulsets of the instance
+func ListWorkloads(ctx context.Context, c client.Client, namespace string, instanceName string) ([]WorkloadStatus, error) {
+	deployments := &appsv1.DeploymentList{}
+	if err := ListBySelector(ctx, c, namespace, InstanceSelector(instanceName), deployments); err != nil {
+		return nil, err
+	}
+	statefulSets := map[string][]appsv1.StatefulSet{}
+	for _, dependence := range Dependences {
+		selector, _ := DependenceSelector(instanceName, dependence)
+		list := &appsv1.StatefulSetList{}
+		if err := ListBySelector(ctx, c, namespace, selector, list); err != nil {
+			return nil, err
+		}
+		statefulSets[dependence] = list.Items
+	}
+	return Workloads(deployments.Items, statefulSets), nil
+}
+
+// Workloads returns the status of the component deployments and of the statefulsets of each dependence, sorted by name
+func Workloads(deployments []appsv1.Deployment, statefulSets map[string][]appsv1.StatefulSet) []WorkloadStatus {
+	workloads := []WorkloadStatus{}
+	for _, deployment := range deployments {
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		workloads = append(workloads, WorkloadStatus{
+			Name:       deployment.Labels["app.kubernetes.io/component"],
+			Kind:       "Deployment",
+			Workload:   deployment.Name,
+			Generation: deployment.Generation,
+			Ready:      deployment.Status.ReadyReplicas,
+			Desired:    desired,
+			RolledOut:  deployment.Status.ObservedGeneration >= deployment.Generation && deployment.Status.UpdatedReplicas >= desired,
+			Selector:   deployment.Spec.Selector,
+		})
+	}
+
+	for _, dependence := range Dependences {
+		for _, statefulSet := range statefulSets[dependence] {
+			name := dependence
+			if len(statefulSets[dependence]) > 1 {
+				// pulsar runs one statefulset per component, named <release>-pulsar-<component>
+				parts := strings.Split(statefulSet.Name, "-")
+				name = dependence + "-" + parts[len(parts)-1]
+			}
+			desired := int32(1)
+			if statefulSet.Spec.Replicas != nil {
+				desired = *statefulSet.Spec.Replicas
+			}
+			workloads = append(workloads, WorkloadStatus{
+				Name:       name,
+				Kind:       "StatefulSet",
+				Workload:   statefulSet.Name,
+				Generation: statefulSet.Generation,
+				Ready:      statefulSet.Status.ReadyReplicas,
+				Desired:    desired,
+				RolledOut:  statefulSet.Status.ObservedGeneration >= statefulSet.Generation,
+				Selector:   statefulSet.Spec.Selector,
+			})
+		}
+	}
+
+	sort.SliceStable(workloads, func(i, j int) bool {
+		return workloads[i].Name < workloads[j].Name
+	})
+	return workloads
+}
+
+// ListBySelector lists the objects of the namespace that match the label selector
+func ListBySelector(ctx context.Context, c client.Client, namespace string, selector string, list client.ObjectList) error {
+	s, err := labels.Parse(selector)
+	if err != nil {
+		return err
+	}
+	return c.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: s})
+}
+
+// Waiter waits for a milvus instance to become healthy or to be deleted and prints the progress of its workloads
+type Waiter struct {
+	Client    client.Client
+	Namespace string
+	Name      string
+	Timeout   time.Duration
+	Interval  time.Duration
+	Out       io.Writer
+	// Baseline is the state of the instance before it was updated, the Healthy status is only
+	// accepted once the operator has reconciled the update
+	Baseline *Baseline
+}
+
+// Baseline is the state of an instance taken before it is updated. The milvus status has no
+// observed generation, right after the update it still reports the Healthy status and the
+// workloads of the previous spec
+type Baseline struct {
+	// Generations are the generations of the workloads by kind/name
+	Generations map[string]int64
+	// Taken is when the baseline was taken
+	Taken time.Time
+	// GracePeriod is how long the Healthy status is not trusted when no workload changes,
+	// zero means DefaultReconcileGracePeriod
+	GracePeriod time.Duration
+}
+
+// NewBaseline records the generations of the workloads of the instance before it is updated
+func NewBaseline(ctx context.Context, c client.Client, namespace string, instanceName string) (*Baseline, error) {
+	workloads, err := ListWorkloads(ctx, c, namespace, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	baseline := &Baseline{Generations: map[string]int64{}, Taken: time.Now()}
+	for _, workload := range workloads {
+		baseline.Generations[workload.Kind+"/"+workload.Workload] = workload.Generation
+	}
+	return baseline, nil
+}
+
+// changed reports if a workload was created or had its spec changed since the baseline
+func (b *Baseline) changed(workloads []WorkloadStatus) bool {
+	for _, workload := range workloads {
+		generation, ok := b.Generations[workload.Kind+"/"+workload.Workload]
+		if !ok || generation != workload.Generation {
+			return true
+		}
+	}
+	return false
+}
+
+// expired reports if the grace period is over
+func (b *Baseline) expired() bool {
+	gracePeriod := b.GracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultReconcileGracePeriod
+	}
+	return time.Since(b.Taken) >= gracePeriod
+}
+
+// WaitForHealthy blocks until the milvus status is Healthy and all workloads are ready,
+// it returns an error when the timeout is reached first. With a baseline, the Healthy status
+// is accepted once a workload changed, the status left Healthy or the grace period is over
+func (w *Waiter) WaitForHealthy(ctx context.Context) error {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+
+	start := time.Now()
+	lastProgress := ""
+	// reconciled is set once the status can be trusted to reflect the update
+	reconciled := w.Baseline == nil
+	key := client.ObjectKey{Namespace: w.Namespace, Name: w.Name}
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		milvus := &v1beta1.Milvus{}
+		if err := w.Client.Get(ctx, key, milvus); err != nil {
+			if errors.IsNotFound(err) {
+				return false, err
+			}
+			// keep polling through transient api errors
+			return false, nil
+		}
+		workloads, err := ListWorkloads(ctx, w.Client, w.Namespace, w.Name)
+		if err != nil {
+			return false, nil
+		}
+
+		progress := formatProgress(milvus, workloads)
+		if progress != lastProgress {
+			fmt.Fprintf(w.Out, "[%s] %s\n", time.Since(start).Round(time.Second), progress)
+			lastProgress = progress
+		}
+
+		if milvus.Status.Status != v1beta1.StatusHealthy {
+			reconciled = true
+			return false, nil
+		}
+		if !reconciled {
+			reconciled = w.Baseline.changed(workloads) || w.Baseline.expired()
+			if !reconciled {
+				return false, nil
+			}
+		}
+		for _, workload := range workloads {
+			if !workload.IsReady() {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out after %s waiting for milvus %s to become %s", timeout, w.Name, v1beta1.StatusHealthy)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w.Out, "milvus.milvus.io/%s is %s\n", w.Name, v1beta1.StatusHealthy)
+	return nil
+}
+
+func formatProgress(milvus *v1beta1.Milvus, workloads []WorkloadStatus) string {
+	status := string(milvus.Status.Status)
+	if status == "" {
+		status = "Pending"
+	}
+	parts := []string{}
+	for _, workload := range workloads {
+		parts = append(parts, fmt.Sprintf("%s %d/%d", workload.Name, workload.Ready, workload.Desired))
+	}
+	progress := status
+	if len(parts) != 0 {
+		progress += ": " + strings.Join(parts, ", ") + " ready"
+	}
+	for _, condition := range milvus.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			progress += fmt.Sprintf("\n  %s=%s %s: %s", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+	return progress
+}
@@ -0,0 +1,291 @@
+package override
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	"github.com/milvus-io/milvusctl/deploy"
+	pkgerr "github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/strvals"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	valuesType   = reflect.TypeOf(v1beta1.Values{})
+	quantityType = reflect.TypeOf(resource.Quantity{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	metaDurType  = reflect.TypeOf(metav1.Duration{})
+	intOrStrType = reflect.TypeOf(intstr.IntOrString{})
+)
+
+// Parse parses --set flags like components.proxy.replicas=2 or
+// components.proxy.env[0].value=x into nested values
+func Parse(sets []string) (map[string]interface{}, error) {
+	base := map[string]interface{}{}
+	for _, value := range sets {
+		if err := strvals.ParseInto(value, base); err != nil {
+			return nil, pkgerr.Wrap(err, "failed parsing --set data")
+		}
+	}
+	return base, nil
+}
+
+// Apply overwrites the fields of the milvus spec addressed by the keys of the
+// values, keys are the json names of the fields. Every invalid key is reported
+// with its full dotted path and the spec is left untouched if there is any.
+func Apply(spec *v1beta1.MilvusSpec, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+	// work on a copy so that a partially applied override never leaks out
+	out := spec.DeepCopy()
+	errs := []error{}
+	setStruct(reflect.ValueOf(out).Elem(), values, "", &errs)
+	if len(errs) != 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	*spec = *out
+	return nil
+}
+
+func setStruct(v reflect.Value, values map[string]interface{}, path string, errs *[]error) {
+	fields := jsonFields(v.Type())
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		keyPath := joinPath(path, key)
+		index, ok := fields[key]
+		if !ok {
+			*errs = append(*errs, fmt.Errorf("%s: the field does not exist", keyPath))
+			continue
+		}
+		setValue(v.FieldByIndex(index), values[key], keyPath, errs)
+	}
+}
+
+// setValue converts the value parsed from --set to the type of v and sets it
+func setValue(v reflect.Value, value interface{}, path string, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if value == nil {
+		// --set key=null resets the field
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+
+	switch v.Type() {
+	case valuesType:
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expects a table of values, got %q", fmt.Sprint(value))
+			return
+		}
+		data := v.Addr().Interface().(*v1beta1.Values)
+		if data.Data == nil {
+			data.Data = map[string]interface{}{}
+		}
+		data.Data = deploy.MergeValues(data.Data, table)
+		return
+	case quantityType:
+		q, err := resource.ParseQuantity(fmt.Sprint(value))
+		if err != nil {
+			fail("invalid quantity %q: %v", fmt.Sprint(value), err)
+			return
+		}
+		v.Set(reflect.ValueOf(q))
+		return
+	case durationType, metaDurType:
+		d, err := time.ParseDuration(fmt.Sprint(value))
+		if err != nil {
+			fail("invalid duration %q: %v", fmt.Sprint(value), err)
+			return
+		}
+		if v.Type() == metaDurType {
+			v.Set(reflect.ValueOf(metav1.Duration{Duration: d}))
+		} else {
+			v.SetInt(int64(d))
+		}
+		return
+	case intOrStrType:
+		switch typed := value.(type) {
+		case int64:
+			v.Set(reflect.ValueOf(intstr.FromInt(int(typed))))
+		default:
+			v.Set(reflect.ValueOf(intstr.Parse(fmt.Sprint(value))))
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		before := len(*errs)
+		setValue(elem.Elem(), value, path, errs)
+		if len(*errs) == before {
+			v.Set(elem)
+		}
+	case reflect.Struct:
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expects a table of fields, got %q", fmt.Sprint(value))
+			return
+		}
+		setStruct(v, table, path, errs)
+	case reflect.Map:
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expects a table, got %q", fmt.Sprint(value))
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		keys := make([]string, 0, len(table))
+		for key := range table {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			mapKey := reflect.ValueOf(key).Convert(v.Type().Key())
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if old := v.MapIndex(mapKey); old.IsValid() {
+				elem.Set(old)
+			}
+			before := len(*errs)
+			setValue(elem, table[key], joinPath(path, key), errs)
+			if len(*errs) == before {
+				v.SetMapIndex(mapKey, elem)
+			}
+		}
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			fail("expects a list, use %s[index]=value or %s={a,b}", path, path)
+			return
+		}
+		// an index may address an item or append right after the last one, a gap would
+		// leave zero items in the list
+		for i := v.Len(); i < len(list); i++ {
+			if list[i] == nil {
+				fail("index %d is out of range, the list has %d items", len(list)-1, v.Len())
+				return
+			}
+		}
+		if len(list) > v.Len() {
+			grown := reflect.MakeSlice(v.Type(), len(list), len(list))
+			reflect.Copy(grown, v)
+			v.Set(grown)
+		}
+		for i, item := range list {
+			// items skipped by the index syntax are left as they are
+			if item == nil {
+				continue
+			}
+			setValue(v.Index(i), item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(value))
+	case reflect.String:
+		if isComposite(value) {
+			fail("expects a string, got a table or list")
+			return
+		}
+		v.SetString(fmt.Sprint(value))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(fmt.Sprint(value))
+		if err != nil || isComposite(value) {
+			fail("expects a bool, got %q", fmt.Sprint(value))
+			return
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(fmt.Sprint(value), 10, 64)
+		if err != nil || isComposite(value) {
+			fail("expects an integer, got %q", fmt.Sprint(value))
+			return
+		}
+		if v.OverflowInt(i) {
+			fail("%d overflows %s", i, v.Type())
+			return
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(fmt.Sprint(value), 10, 64)
+		if err != nil || isComposite(value) {
+			fail("expects an unsigned integer, got %q", fmt.Sprint(value))
+			return
+		}
+		if v.OverflowUint(u) {
+			fail("%d overflows %s", u, v.Type())
+			return
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		if err != nil || isComposite(value) {
+			fail("expects a number, got %q", fmt.Sprint(value))
+			return
+		}
+		v.SetFloat(f)
+	default:
+		fail("can not be overwritten by --set")
+	}
+}
+
+// jsonFields maps the json names of the struct fields to their index,
+// fields of inline structs are promoted like encoding/json does
+func jsonFields(t reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for key, index := range jsonFields(field.Type) {
+				// an outer field with the same name wins
+				if _, ok := fields[key]; !ok {
+					fields[key] = append([]int{i}, index...)
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = []int{i}
+	}
+	return fields
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func isComposite(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package override
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/milvus-io/milvus-operator/apis/milvus.io/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func proxySpec() *v1beta1.MilvusSpec {
+	spec := &v1beta1.MilvusSpec{Mode: v1beta1.MilvusModeCluster}
+	spec.Com.Proxy = &v1beta1.MilvusProxy{}
+	spec.Com.Proxy.Replicas = pointer.Int32(1)
+	spec.Com.Proxy.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}}
+	return spec
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		sets []string
+		// check verifies the spec after a successful Apply
+		check func(t *testing.T, spec *v1beta1.MilvusSpec)
+		// errs are the substrings expected in the error, the spec must be left untouched
+		errs []string
+	}{
+		{
+			name: "int32 pointer",
+			sets: []string{"components.proxy.replicas=3"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				if spec.Com.Proxy.Replicas == nil || *spec.Com.Proxy.Replicas != 3 {
+					t.Errorf("replicas = %v, want 3", spec.Com.Proxy.Replicas)
+				}
+			},
+		},
+		{
+			name: "int32 pointer of a nil component",
+			sets: []string{"components.queryNode.replicas=2"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				if spec.Com.QueryNode == nil || spec.Com.QueryNode.Replicas == nil || *spec.Com.QueryNode.Replicas != 2 {
+					t.Errorf("queryNode = %+v, want 2 replicas", spec.Com.QueryNode)
+				}
+			},
+		},
+		{
+			name: "quantity",
+			sets: []string{"components.proxy.resources.limits.memory=2Gi", "components.proxy.resources.requests.cpu=500m"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				resources := spec.Com.Proxy.Resources
+				if resources == nil {
+					t.Fatal("resources are not set")
+				}
+				if q := resources.Limits[corev1.ResourceMemory]; q.Cmp(resource.MustParse("2Gi")) != 0 {
+					t.Errorf("memory limit = %s, want 2Gi", q.String())
+				}
+				if q := resources.Requests[corev1.ResourceCPU]; q.Cmp(resource.MustParse("500m")) != 0 {
+					t.Errorf("cpu request = %s, want 500m", q.String())
+				}
+			},
+		},
+		{
+			name: "string and bool",
+			sets: []string{"components.image=milvusdb/milvus:v2.1.0,components.disableMetric=true"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				if spec.Com.Image != "milvusdb/milvus:v2.1.0" {
+					t.Errorf("image = %q", spec.Com.Image)
+				}
+				if !spec.Com.DisableMetric {
+					t.Error("disableMetric = false, want true")
+				}
+			},
+		},
+		{
+			name: "list index of an existing item",
+			sets: []string{"components.proxy.env[0].value=debug"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				want := []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+				if !reflect.DeepEqual(spec.Com.Proxy.Env, want) {
+					t.Errorf("env = %+v, want %+v", spec.Com.Proxy.Env, want)
+				}
+			},
+		},
+		{
+			name: "list index appending an item",
+			sets: []string{"components.proxy.env[1].name=GOGC,components.proxy.env[1].value=200"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				want := []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}, {Name: "GOGC", Value: "200"}}
+				if !reflect.DeepEqual(spec.Com.Proxy.Env, want) {
+					t.Errorf("env = %+v, want %+v", spec.Com.Proxy.Env, want)
+				}
+			},
+		},
+		{
+			name: "values are merged",
+			sets: []string{"config.common.retentionDuration=3600"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				common, _ := spec.Conf.Data["common"].(map[string]interface{})
+				if common["retentionDuration"] != int64(3600) {
+					t.Errorf("config = %+v", spec.Conf.Data)
+				}
+			},
+		},
+		{
+			name: "null resets the field",
+			sets: []string{"components.proxy.replicas=null"},
+			check: func(t *testing.T, spec *v1beta1.MilvusSpec) {
+				if spec.Com.Proxy.Replicas != nil {
+					t.Errorf("replicas = %d, want nil", *spec.Com.Proxy.Replicas)
+				}
+			},
+		},
+		{
+			name: "unknown field",
+			sets: []string{"components.proxy.replica=3"},
+			errs: []string{"components.proxy.replica: the field does not exist"},
+		},
+		{
+			name: "unknown nested field",
+			sets: []string{"dependencies.etcd.inCluster.foo.bar=1"},
+			errs: []string{"dependencies.etcd.inCluster.foo: the field does not exist"},
+		},
+		{
+			name: "every invalid key is reported",
+			sets: []string{"components.foo=1,components.proxy.bar=2,components.proxy.replicas=3"},
+			errs: []string{"components.foo: the field does not exist", "components.proxy.bar: the field does not exist"},
+		},
+		{
+			name: "string for an integer",
+			sets: []string{"components.proxy.replicas=two"},
+			errs: []string{`components.proxy.replicas: expects an integer, got "two"`},
+		},
+		{
+			name: "bool for an integer",
+			sets: []string{"components.proxy.replicas=true"},
+			errs: []string{`components.proxy.replicas: expects an integer, got "true"`},
+		},
+		{
+			name: "integer overflow",
+			sets: []string{"components.proxy.replicas=4294967296"},
+			errs: []string{"components.proxy.replicas: 4294967296 overflows int32"},
+		},
+		{
+			name: "invalid quantity",
+			sets: []string{"components.proxy.resources.limits.memory=lots"},
+			errs: []string{`components.proxy.resources.limits.memory: invalid quantity "lots"`},
+		},
+		{
+			name: "value for a struct",
+			sets: []string{"components.proxy=3"},
+			errs: []string{`components.proxy: expects a table of fields, got "3"`},
+		},
+		{
+			name: "table for a string",
+			sets: []string{"components.image.tag=v2"},
+			errs: []string{"components.image: expects a string, got a table or list"},
+		},
+		{
+			name: "value for a list",
+			sets: []string{"components.proxy.env=x"},
+			errs: []string{"components.proxy.env: expects a list"},
+		},
+		{
+			name: "invalid bool",
+			sets: []string{"components.disableMetric=maybe"},
+			errs: []string{`components.disableMetric: expects a bool, got "maybe"`},
+		},
+		{
+			name: "out of range index",
+			sets: []string{"components.proxy.env[3].value=x"},
+			errs: []string{"components.proxy.env: index 3 is out of range, the list has 1 items"},
+		},
+		{
+			name: "error in an indexed item",
+			sets: []string{"components.proxy.env[0].foo=x"},
+			errs: []string{"components.proxy.env[0].foo: the field does not exist"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := Parse(tt.sets)
+			if err != nil {
+				t.Fatalf("Parse(%v) failed: %v", tt.sets, err)
+			}
+			spec := proxySpec()
+			err = Apply(spec, values)
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatalf("Apply(%v) failed: %v", tt.sets, err)
+				}
+				tt.check(t, spec)
+				return
+			}
+			if err == nil {
+				t.Fatalf("Apply(%v) succeeded, want an error", tt.sets)
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err.Error(), want)
+				}
+			}
+			if !reflect.DeepEqual(spec, proxySpec()) {
+				t.Errorf("the spec was changed by a failed Apply: %+v", spec)
+			}
+		})
+	}
+}
+
+func TestSetValueDuration(t *testing.T) {
+	type durations struct {
+		Timeout  time.Duration   `json:"timeout"`
+		Interval metav1.Duration `json:"interval"`
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  durations
+		err   string
+	}{
+		{
+			name:  "durations",
+			value: map[string]interface{}{"timeout": "90s", "interval": "5m"},
+			want:  durations{Timeout: 90 * time.Second, Interval: metav1.Duration{Duration: 5 * time.Minute}},
+		},
+		{
+			name:  "invalid duration",
+			value: map[string]interface{}{"timeout": "soon"},
+			err:   `spec.timeout: invalid duration "soon"`,
+		},
+		{
+			name:  "integer without unit",
+			value: map[string]interface{}{"interval": int64(10)},
+			err:   `spec.interval: invalid duration "10"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := durations{}
+			errs := []error{}
+			setValue(reflect.ValueOf(&got).Elem(), tt.value, "spec", &errs)
+			if tt.err == "" {
+				if len(errs) != 0 {
+					t.Fatalf("setValue failed: %v", errs)
+				}
+				if got != tt.want {
+					t.Errorf("got %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.err) {
+				t.Errorf("errors %v, want %q", errs, tt.err)
+			}
+		})
+	}
+}
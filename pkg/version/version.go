@@ -0,0 +1,38 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Version is the milvusctl release, set at build time with
+// -ldflags "-X github.com/milvus-io/milvusctl/pkg/version.Version=v0.1.0"
+var Version = ""
+
+// Info is the version of the milvusctl binary
+type Info struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("milvusctl %s (%s, %s)", i.Version, i.GoVersion, i.Platform)
+}
+
+// Get returns the version of milvusctl, the module version is used when it is not set at build time
+func Get() Info {
+	version := Version
+	if version == "" {
+		version = "unknown"
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+			version = info.Main.Version
+		}
+	}
+	return Info{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}